@@ -6,9 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/limit"
+	"github.com/lufia/news/thread"
 	"golang.org/x/net/html"
 )
 
@@ -119,9 +123,23 @@ type Person struct {
 
 // LinkはAtom文書におけるLinkコンストラクトをあらわす。
 type Link struct {
-	Rel  string `xml:"rel,attr,omitempty"`
-	Type string `xml:"type,attr,omitempty"`
-	URL  string `xml:"href,attr"`
+	Rel      string `xml:"rel,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	URL      string `xml:"href,attr"`
+	HrefLang string `xml:"hreflang,attr,omitempty"`
+	Title    string `xml:"title,attr,omitempty"`
+	Length   int64  `xml:"length,attr,omitempty"`
+
+	// Atom Threading Extensions (RFC 4685)
+	Count   int       `xml:"http://purl.org/syndication/thread/1.0 count,attr,omitempty"`
+	Updated time.Time `xml:"http://purl.org/syndication/thread/1.0 updated,attr,omitempty"`
+}
+
+// GeneratorはAtom文書におけるGenerator要素をあらわす。
+type Generator struct {
+	URL     string `xml:"uri,attr,omitempty"`
+	Version string `xml:"version,attr,omitempty"`
+	Name    string `xml:",chardata"`
 }
 
 // FeedはAtom文書におけるFeed要素をあらわす。
@@ -132,9 +150,6 @@ type Feed struct {
 	//Lang string `xml:"lang,attr,omitempty"`
 
 	//Contributors []Person `xml:"contributor,omitempty"`
-	//Generator Generator `xml:"generator,omitempty"`
-	//Icon string `xml:"icon,omitempty"`
-	//Logo string `xml:"logo,omitempty"`
 
 	Title      Text       `xml:"title"`
 	Subtitle   Text       `xml:"subtitle,omitempty"`
@@ -145,13 +160,38 @@ type Feed struct {
 	Updated    time.Time  `xml:"updated"`
 	Summary    string     `xml:"summary,omitempty"`
 	Categories []Category `xml:"category,omitempty"`
+	Generator  *Generator `xml:"generator,omitempty"`
+	Icon       string     `xml:"icon,omitempty"`
+	Logo       string     `xml:"logo,omitempty"`
 	Entries    []*Entry   `xml:"entry"`
+
+	// Atom Tombstones (RFC 6721)
+	DeletedEntries []*DeletedEntry `xml:"http://purl.org/atompub/tombstones/1.0 deleted-entry,omitempty"`
+
+	Extensions []extension.Element `xml:",any"`
 }
 
 func (feed *Feed) AlternateURL() string {
 	return alternateURL(feed.Links)
 }
 
+// ImageURLはLogo、なければIconのURLを返す。
+func (feed *Feed) ImageURL() string {
+	if feed.Logo != "" {
+		return feed.Logo
+	}
+	return feed.Icon
+}
+
+// DeletedEntryはAtom Tombstones(RFC 6721)におけるdeleted-entry要素をあらわす。
+type DeletedEntry struct {
+	Ref     string    `xml:"ref,attr"`
+	When    time.Time `xml:"when,attr"`
+	By      *Person   `xml:"http://purl.org/atompub/tombstones/1.0 by,omitempty"`
+	Comment Text      `xml:"http://purl.org/atompub/tombstones/1.0 comment,omitempty"`
+	Links   []Link    `xml:"link,omitempty"`
+}
+
 // EntryはAtom文書におけるEntry要素をあらわす。
 type Entry struct {
 	//Contributors []Person `xml:"contributor,omitempty"`
@@ -172,12 +212,18 @@ type Entry struct {
 	// atom 0.3 compatibility
 	Modified time.Time `xml:"modified,omitempty"`
 	Issued   time.Time `xml:"issued,omitempty"`
+
+	Extensions []extension.Element `xml:",any"`
 }
 
 func (entry *Entry) Article() string {
 	return ""
 }
 
+func (entry *DeletedEntry) AlternateURL() string {
+	return alternateURL(entry.Links)
+}
+
 func (entry *Entry) AlternateURL() string {
 	return alternateURL(entry.Links)
 }
@@ -196,6 +242,96 @@ func (entry *Entry) UpdatedTime() time.Time {
 	return entry.Modified
 }
 
+// RepliesはrelがrepliesのLinkを返す。
+func (entry *Entry) Replies() []Link {
+	var a []Link
+	for _, link := range entry.Links {
+		if link.Rel == "replies" {
+			a = append(a, link)
+		}
+	}
+	return a
+}
+
+const (
+	// NamespaceはAtom 1.0の名前空間をあらわす。
+	Namespace = "http://www.w3.org/2005/Atom"
+
+	// Namespace03はAtom 0.3の名前空間をあらわす。
+	Namespace03 = "http://purl.org/atom/ns#"
+
+	// TombstonesNamespaceはAtom Tombstones(RFC 6721)の名前空間をあらわす。
+	TombstonesNamespace = "http://purl.org/atompub/tombstones/1.0"
+)
+
+// isNativeはnameがAtomの要素ならtrueを返す。
+func isNative(name xml.Name) bool {
+	switch name.Space {
+	case "", Namespace, Namespace03, TombstonesNamespace:
+		return true
+	}
+	return false
+}
+
+// UnmarshalXMLはLink要素を復号する。
+// lengthは目安でしかなく、thr:countとthr:updatedは拡張なので、
+// 解析できない値はエラーにせず無視する。
+func (link *Link) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var length, count, updated string
+	var attrs []xml.Attr // copy of start.Attr without them; nil if no need
+	for i, a := range start.Attr {
+		switch {
+		case a.Name.Space == "" && a.Name.Local == "length":
+			length = a.Value
+		case a.Name.Space == thread.Namespace && a.Name.Local == "count":
+			count = a.Value
+		case a.Name.Space == thread.Namespace && a.Name.Local == "updated":
+			updated = a.Value
+		default:
+			if attrs != nil {
+				attrs = append(attrs, a)
+			}
+			continue
+		}
+		if attrs == nil {
+			attrs = append(make([]xml.Attr, 0, len(start.Attr)), start.Attr[:i]...)
+		}
+	}
+	if attrs != nil {
+		start.Attr = attrs
+	}
+	type plain Link
+	if err := d.DecodeElement((*plain)(link), &start); err != nil {
+		return err
+	}
+	if length != "" {
+		if n, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64); err == nil && n >= 0 {
+			link.Length = n
+		}
+	}
+	if count != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(count)); err == nil {
+			link.Count = n
+		}
+	}
+	if updated != "" {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(updated)); err == nil {
+			link.Updated = t
+		}
+	}
+	return nil
+}
+
+func (feed *Feed) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Feed
+	return extension.DecodeElement(d, (*plain)(feed), &start, isNative, &feed.Extensions)
+}
+
+func (entry *Entry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Entry
+	return extension.DecodeElement(d, (*plain)(entry), &start, isNative, &entry.Extensions)
+}
+
 func alternateURL(links []Link) string {
 	for _, link := range links {
 		if link.Rel == "alternate" || link.Rel == "" {
@@ -205,9 +341,24 @@ func alternateURL(links []Link) string {
 	return ""
 }
 
+// Extensionは名前空間nsの要素をextensionパッケージに登録されたデコーダで変換した値を返す。
+func (feed *Feed) Extension(ns string) (interface{}, error) {
+	return extension.New(feed.Extensions).Decode(ns)
+}
+
+// Extensionは名前空間nsの要素をextensionパッケージに登録されたデコーダで変換した値を返す。
+func (entry *Entry) Extension(ns string) (interface{}, error) {
+	return extension.New(entry.Extensions).Decode(ns)
+}
+
+// Parseはrから読み込んだAtom文書を返す。limit.Defaultを超える文書はエラーになる。
 func Parse(r io.Reader) (feed *Feed, err error) {
+	return Decode(limit.Default.NewDecoder(r))
+}
+
+// Decodeはdから読み込んだAtom文書を返す。
+func Decode(d *xml.Decoder) (feed *Feed, err error) {
 	var x Feed
-	d := xml.NewDecoder(r)
 	err = d.Decode(&x)
 	if err != nil {
 		return
@@ -215,3 +366,14 @@ func Parse(r io.Reader) (feed *Feed, err error) {
 	feed = &x
 	return
 }
+
+// DecodeElementはdから読み込んだAtom文書を返す。startはdから読み込み済みのルート要素。
+func DecodeElement(d *xml.Decoder, start *xml.StartElement) (feed *Feed, err error) {
+	var x Feed
+	err = d.DecodeElement(&x, start)
+	if err != nil {
+		return
+	}
+	feed = &x
+	return
+}
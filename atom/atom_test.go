@@ -2,10 +2,13 @@ package atom
 
 import (
 	"encoding/xml"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/lufia/news/limit"
 )
 
 func TestParse(t *testing.T) {
@@ -140,3 +143,96 @@ var xmlStringSimple = strings.TrimSpace(`
 	</entry>
 </feed>
 `)
+
+func TestParseDeletedEntry(t *testing.T) {
+	s := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:at="http://purl.org/atompub/tombstones/1.0">
+	<title>Example Feed</title>
+	<at:deleted-entry ref="tag:example.org,2005:/entries/1" when="2005-11-29T12:11:12Z">
+		<at:by>
+			<name>John Doe</name>
+			<email>jdoe@example.org</email>
+		</at:by>
+		<at:comment xml:lang="la">Lorem ipsum dolor</at:comment>
+		<link href="http://example.org/entries/1"/>
+	</at:deleted-entry>
+</feed>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", s, err)
+	}
+	want := []*DeletedEntry{
+		&DeletedEntry{
+			Ref:     "tag:example.org,2005:/entries/1",
+			When:    time.Date(2005, 11, 29, 12, 11, 12, 0, time.UTC),
+			By:      &Person{Name: "John Doe", Email: "jdoe@example.org"},
+			Comment: S("Lorem ipsum dolor"),
+			Links:   URLs("http://example.org/entries/1"),
+		},
+	}
+	if !reflect.DeepEqual(feed.DeletedEntries, want) {
+		t.Errorf("DeletedEntries = %#v; want %#v", feed.DeletedEntries, want)
+	}
+	if len(feed.Extensions) != 0 {
+		t.Errorf("Extensions = %v; want empty", feed.Extensions)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(xmlStringSimple)
+	f.Fuzz(func(t *testing.T, s string) {
+		Parse(strings.NewReader(s))
+	})
+}
+
+func TestParseLimits(t *testing.T) {
+	s := `<feed xmlns="http://www.w3.org/2005/Atom">` + strings.Repeat("<a>", limit.Default.MaxDepth) + strings.Repeat("</a>", limit.Default.MaxDepth) + `</feed>`
+	_, err := Parse(strings.NewReader(s))
+	var e *limit.Error
+	if !errors.As(err, &e) || e.Name != "MaxDepth" {
+		t.Errorf("Parse = %v; want MaxDepth error", err)
+	}
+}
+
+func TestDecodeLinkThread(t *testing.T) {
+	s := `<link xmlns:thr="http://purl.org/syndication/thread/1.0" rel="replies"
+		thr:count="3" href="http://example.com/1/replies" thr:updated="2015-01-02T03:04:05Z" type="text/html"/>`
+	var link Link
+	if err := xml.Unmarshal([]byte(s), &link); err != nil {
+		t.Fatalf("Unmarshal = %v", err)
+	}
+	want := Link{
+		Rel:     "replies",
+		URL:     "http://example.com/1/replies",
+		Type:    "text/html",
+		Count:   3,
+		Updated: time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(link, want) {
+		t.Errorf("Unmarshal = %+v; want %+v", link, want)
+	}
+}
+
+func TestDecodeLinkLength(t *testing.T) {
+	tab := []struct {
+		S    string
+		Want int64
+	}{
+		{S: "1024", Want: 1024},
+		{S: "", Want: 0},
+		{S: "unknown", Want: 0},
+		{S: "-1", Want: 0},
+	}
+	for _, v := range tab {
+		s := `<link rel="enclosure" href="http://example.com/a.mp3" length="` + v.S + `" type="audio/mpeg"/>`
+		var link Link
+		if err := xml.Unmarshal([]byte(s), &link); err != nil {
+			t.Errorf("Unmarshal(%q) = %v", s, err)
+			continue
+		}
+		want := Link{Rel: "enclosure", URL: "http://example.com/a.mp3", Type: "audio/mpeg", Length: v.Want}
+		if !reflect.DeepEqual(link, want) {
+			t.Errorf("Unmarshal(%q) = %+v; want %+v", s, link, want)
+		}
+	}
+}
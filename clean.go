@@ -0,0 +1,95 @@
+package news
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// isXMLChar reports whether c is in the Char production of XML 1.0.
+func isXMLChar(c rune) bool {
+	switch {
+	case c == '\t' || c == '\n' || c == '\r':
+		return true
+	case c >= 0x20 && c <= 0xD7FF:
+		return true
+	case c >= 0xE000 && c <= 0xFFFD:
+		return true
+	case c >= 0x10000 && c <= utf8.MaxRune:
+		return true
+	}
+	return false
+}
+
+// appendClean appends characters of p to dst; it discards characters
+// that are not allowed in XML 1.0, and replaces invalid UTF-8 sequences
+// with utf8.RuneError.
+// If atEOF is false, a incomplete rune at the end of p is not consumed.
+// It returns the extended buffer and the number of bytes consumed.
+func appendClean(dst, p []byte, atEOF bool) ([]byte, int) {
+	i := 0
+	for i < len(p) {
+		if c := p[i]; c < utf8.RuneSelf {
+			if isXMLChar(rune(c)) {
+				dst = append(dst, c)
+			}
+			i++
+			continue
+		}
+		if !atEOF && !utf8.FullRune(p[i:]) {
+			break
+		}
+		c, n := utf8.DecodeRune(p[i:])
+		switch {
+		case c == utf8.RuneError && n == 1:
+			dst = append(dst, string(utf8.RuneError)...)
+		case isXMLChar(c):
+			dst = append(dst, p[i:i+n]...)
+		}
+		i += n
+	}
+	return dst, i
+}
+
+// Cleanup discards invalid chars in XML 1.0.
+// Invalid UTF-8 sequences are replaced with U+FFFD.
+func Cleanup(p []byte) []byte {
+	buf, _ := appendClean(make([]byte, 0, len(p)), p, true)
+	return buf
+}
+
+// CleanReader is an io.Reader that discards invalid chars in XML 1.0
+// from the underlying UTF-8 encoded reader in a streaming manner.
+// Invalid UTF-8 sequences are replaced with U+FFFD.
+type CleanReader struct {
+	r   io.Reader
+	in  []byte // bytes read but not consumed yet
+	out []byte // cleaned bytes not returned yet
+	err error
+}
+
+// NewCleanReader returns a CleanReader reading from r.
+func NewCleanReader(r io.Reader) *CleanReader {
+	return &CleanReader{r: r}
+}
+
+const cleanReaderBufSize = 4096
+
+func (r *CleanReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if cap(r.in) == 0 {
+			r.in = make([]byte, 0, cleanReaderBufSize)
+		}
+		n, err := r.r.Read(r.in[len(r.in):cap(r.in)])
+		r.in = r.in[:len(r.in)+n]
+		r.err = err
+		var m int
+		r.out, m = appendClean(r.out[:0], r.in, err != nil)
+		r.in = r.in[:copy(r.in, r.in[m:])]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package news
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var cleanTests = []struct {
+	s    string
+	want string
+}{
+	{s: "abc", want: "abc"},
+	{s: "abc\v", want: "abc"},
+	{s: "\vabc", want: "abc"},
+	{s: "\va\vb\vc\v", want: "abc"},
+	{s: "\v\v", want: ""},
+	{s: "a\tb\nc\r", want: "a\tb\nc\r"},
+	{s: "a\x00b\x0cc\x1f", want: "abc"},
+	{s: "日本語", want: "日本語"},
+	{s: "a￾b￿c", want: "abc"},
+	{s: "a\U0001F600b", want: "a\U0001F600b"},
+	{s: "a\xffb", want: "a�b"},
+	{s: "a\xed\xa0\x80b", want: "a���b"}, // lone surrogate
+	{s: "abc\xe6\x97", want: "abc��"},    // truncated at EOF
+}
+
+func TestCleanup(t *testing.T) {
+	for _, v := range cleanTests {
+		r := Cleanup([]byte(v.s))
+		s := string(r)
+		if s != v.want {
+			t.Errorf("Cleanup(%q) = %q; want %q", v.s, s, v.want)
+		}
+	}
+}
+
+func TestCleanReader(t *testing.T) {
+	for _, v := range cleanTests {
+		r := NewCleanReader(iotest.OneByteReader(strings.NewReader(v.s)))
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Errorf("ReadAll(%q) = %v", v.s, err)
+			continue
+		}
+		if s := string(buf); s != v.want {
+			t.Errorf("ReadAll(%q) = %q; want %q", v.s, s, v.want)
+		}
+	}
+}
+
+func TestCleanReaderLarge(t *testing.T) {
+	s := strings.Repeat("日本語\v", 10000)
+	want := strings.Repeat("日本語", 10000)
+	buf, err := ioutil.ReadAll(NewCleanReader(strings.NewReader(s)))
+	if err != nil {
+		t.Fatalf("ReadAll = %v", err)
+	}
+	if string(buf) != want {
+		t.Errorf("ReadAll returned %d bytes; want %d bytes", len(buf), len(want))
+	}
+}
+
+func TestParseWithControlChars(t *testing.T) {
+	r := strings.NewReader("<?xml version=\"1.0\"?>\n" +
+		"<rss version=\"2.0\"><channel><title>a\x0cb\x00c</title></channel></rss>")
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if feed.Title != "abc" {
+		t.Errorf("Title = %q; want %q", feed.Title, "abc")
+	}
+}
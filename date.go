@@ -0,0 +1,325 @@
+package news
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/dublincore"
+	"github.com/lufia/news/rss2"
+	"github.com/lufia/news/syndication"
+	"github.com/lufia/news/thread"
+)
+
+var (
+	// minValidTime is the lower bound of valid dates;
+	// dates around the Unix epoch are mostly broken.
+	minValidTime = time.Date(1971, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// maxClockSkew is the tolerance of dates in future.
+	maxClockSkew = 24 * time.Hour
+)
+
+// isValidDate reports whether t is a plausible date of a document fetched at now.
+func isValidDate(t, now time.Time) bool {
+	return !t.Before(minValidTime) && !t.After(now.Add(maxClockSkew))
+}
+
+// normalizeDates converts dates in feed to loc. The original dates are
+// preserved in Original fields of articles. If an article has no valid
+// published date, the date is replaced with the updated date,
+// or the time that is returned by firstSeen as an estimate.
+func (feed *Feed) normalizeDates(loc *time.Location, now time.Time, firstSeen func(p *Article) time.Time) {
+	if isValidDate(feed.Updated, now) {
+		feed.Updated = feed.Updated.In(loc)
+	} else {
+		feed.Updated = time.Time{}
+	}
+	for _, p := range feed.Articles {
+		p.OriginalPublished = p.Published
+		p.OriginalUpdated = p.Updated
+		if !isValidDate(p.Updated, now) {
+			p.Updated = time.Time{}
+		}
+		switch {
+		case isValidDate(p.Published, now):
+		case !p.Updated.IsZero():
+			p.Published = p.Updated
+		default:
+			p.Published = now
+			if firstSeen != nil {
+				p.Published = firstSeen(p)
+			}
+			p.Estimated = true
+		}
+		p.Published = p.Published.In(loc)
+		if !p.Updated.IsZero() {
+			p.Updated = p.Updated.In(loc)
+		}
+	}
+}
+
+// zoneOffsets are offsets of time zone abbreviations that appear in feeds.
+// time.Parse treats unknown abbreviations as UTC.
+var zoneOffsets = map[string]int{
+	"UT":   0,
+	"UTC":  0,
+	"GMT":  0,
+	"Z":    0,
+	"EST":  -5 * 60 * 60,
+	"EDT":  -4 * 60 * 60,
+	"CST":  -6 * 60 * 60,
+	"CDT":  -5 * 60 * 60,
+	"MST":  -7 * 60 * 60,
+	"MDT":  -6 * 60 * 60,
+	"PST":  -8 * 60 * 60,
+	"PDT":  -7 * 60 * 60,
+	"AKST": -9 * 60 * 60,
+	"AKDT": -8 * 60 * 60,
+	"HST":  -10 * 60 * 60,
+	"WET":  0,
+	"WEST": 1 * 60 * 60,
+	"BST":  1 * 60 * 60,
+	"CET":  1 * 60 * 60,
+	"CEST": 2 * 60 * 60,
+	"MET":  1 * 60 * 60,
+	"MEST": 2 * 60 * 60,
+	"EET":  2 * 60 * 60,
+	"EEST": 3 * 60 * 60,
+	"MSK":  3 * 60 * 60,
+	"JST":  9 * 60 * 60,
+	"KST":  9 * 60 * 60,
+	"HKT":  8 * 60 * 60,
+	"SGT":  8 * 60 * 60,
+	"AEST": 10 * 60 * 60,
+	"AEDT": 11 * 60 * 60,
+	"NZST": 12 * 60 * 60,
+	"NZDT": 13 * 60 * 60,
+}
+
+// zoneDateLayouts are layouts of dates that have time zone abbreviations.
+var zoneDateLayouts = []string{
+	"Mon, _2 Jan 2006 15:04:05 MST",
+	"Mon, _2 Jan 2006 15:04 MST",
+	"Mon, _2 Jan 06 15:04:05 MST",
+	"_2 Jan 2006 15:04:05 MST",
+	"Mon, _2 January 2006 15:04:05 MST",
+}
+
+// parseZoneDate parses s that has a time zone abbreviation.
+func parseZoneDate(s string) (time.Time, bool) {
+	for _, l := range zoneDateLayouts {
+		t, err := time.Parse(l, s)
+		if err != nil {
+			continue
+		}
+		name, _ := t.Zone()
+		off, ok := zoneOffsets[strings.ToUpper(name)]
+		if !ok {
+			return time.Time{}, false
+		}
+		loc := time.FixedZone(name, off)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), true
+	}
+	return time.Time{}, false
+}
+
+const rss2DateLayout = "Mon, 02 Jan 2006 15:04:05 -0700"
+
+// localDateLayouts are layouts of dates that have no time zone.
+var localDateLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+	"Mon, _2 Jan 2006 15:04:05",
+	"Mon, _2 Jan 2006 15:04",
+	"_2 Jan 2006 15:04:05",
+}
+
+// offsetDateLayouts are layouts of dates that have numeric time zones.
+var offsetDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"Mon, _2 Jan 2006 15:04:05 -0700",
+	"Mon, _2 Jan 2006 15:04 -0700",
+	"Mon, _2 Jan 06 15:04:05 -0700",
+	"_2 Jan 2006 15:04:05 -0700",
+}
+
+// dateTokenReader is a xml.TokenReader that rewrites dates in the document
+// to the layout of the dialect. Dates that have no time zone are
+// interpreted in loc, and time zone abbreviations are replaced with
+// numeric offsets. If lenient is true, it removes dates that can't be parsed.
+type dateTokenReader struct {
+	r       xml.TokenReader
+	loc     *time.Location
+	lenient bool
+	dialect Dialect // nil until the root element is read
+	root    bool    // the root element was read
+	pending []xml.Token
+}
+
+func (r *dateTokenReader) Token() (xml.Token, error) {
+	for {
+		if len(r.pending) > 0 {
+			tok := r.pending[0]
+			r.pending = r.pending[1:]
+			return tok, nil
+		}
+		tok, err := r.r.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			return tok, nil
+		}
+		if !r.root {
+			r.root = true
+			r.dialect, _ = detectDialect(start)
+		}
+		layout := r.dateLayout(start.Name)
+		if !r.hasDateAttr(start) {
+			if layout == "" {
+				return tok, nil
+			}
+		} else {
+			start.Attr = r.normalizeAttrs(start)
+			if layout == "" {
+				return start, nil
+			}
+		}
+		var text []byte
+		for {
+			tok, err = r.r.Token()
+			if err != nil {
+				return nil, err
+			}
+			s, ok := tok.(xml.CharData)
+			if !ok {
+				break
+			}
+			text = append(text, s...)
+		}
+		s, ok := r.normalize(string(text), layout)
+		if _, end := tok.(xml.EndElement); end && !ok && r.lenient {
+			continue
+		}
+		r.pending = append(r.pending, xml.CharData(s), xml.CopyToken(tok))
+		return start, nil
+	}
+}
+
+func (r *dateTokenReader) hasDateAttr(start xml.StartElement) bool {
+	for _, a := range start.Attr {
+		if isDateAttr(start.Name, a.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *dateTokenReader) normalizeAttrs(start xml.StartElement) []xml.Attr {
+	attrs := start.Attr[:0]
+	for _, a := range start.Attr {
+		if isDateAttr(start.Name, a.Name) {
+			s, ok := r.normalize(a.Value, time.RFC3339Nano)
+			if !ok && r.lenient {
+				continue
+			}
+			a.Value = s
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// normalize returns s that is formatted in layout.
+// If s is already valid in layout, it returns s as is.
+// It reports whether s is a valid date.
+func (r *dateTokenReader) normalize(s, layout string) (string, bool) {
+	v := strings.TrimSpace(s)
+	// dates in RFC 3339 are common; zones of RFC 822 dates need to be checked first.
+	if layout != rss2DateLayout && isValidLayout(v, layout) {
+		return s, true
+	}
+	if t, ok := parseZoneDate(v); ok {
+		return t.Format(layout), true
+	}
+	if isValidLayout(v, layout) {
+		return s, true
+	}
+	for _, l := range localDateLayouts {
+		if t, err := time.ParseInLocation(l, v, r.loc); err == nil {
+			return t.Format(layout), true
+		}
+	}
+	for _, l := range offsetDateLayouts {
+		if t, err := time.Parse(l, v); err == nil {
+			return t.Format(layout), true
+		}
+	}
+	return s, false
+}
+
+// isValidLayout reports whether the dialect parser can parse s as the layout.
+func isValidLayout(s, layout string) bool {
+	var layouts []string
+	switch layout {
+	case rss2DateLayout:
+		layouts = []string{rss2.RFC2822, rss2.RFC2822Z}
+	default:
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if _, err := time.Parse(l, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayout returns the layout of the element name if it is a date.
+func (r *dateTokenReader) dateLayout(name xml.Name) string {
+	switch name.Space {
+	case "":
+		if r.dialect == RSS2 && (name.Local == "pubDate" || name.Local == "lastBuildDate") {
+			return rss2DateLayout
+		}
+	case atom.Namespace, atom.Namespace03:
+		switch name.Local {
+		case "updated", "published", "modified", "issued", "created":
+			return time.RFC3339Nano
+		}
+	case dublincore.Namespace:
+		if name.Local == "date" {
+			return time.RFC3339Nano
+		}
+	case dublincore.TermsNamespace:
+		if name.Local == "modified" {
+			return time.RFC3339Nano
+		}
+	case syndication.Namespace:
+		if name.Local == "updateBase" {
+			return time.RFC3339Nano
+		}
+	}
+	return ""
+}
+
+// isDateAttr reports whether the attribute attr of the element elem is a date.
+func isDateAttr(elem, attr xml.Name) bool {
+	switch {
+	case elem.Space == atom.TombstonesNamespace && elem.Local == "deleted-entry":
+		return attr.Space == "" && attr.Local == "when"
+	case attr.Space == thread.Namespace:
+		return attr.Local == "updated"
+	}
+	return false
+}
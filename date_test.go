@@ -0,0 +1,131 @@
+package news
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rss2WithPubDate(s string) string {
+	return fmt.Sprintf(`<rss version="2.0"><channel><title>t</title>
+		<item><title>a</title><link>http://example.com/a</link>%s</item>
+	</channel></rss>`, s)
+}
+
+func TestParseDateZone(t *testing.T) {
+	s := rss2WithPubDate(`<pubDate>Fri, 02 Jan 2015 12:04:05 JST</pubDate>`)
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	p := feed.Articles[0]
+	want := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !p.Published.Equal(want) || p.Published.Location() != time.UTC {
+		t.Errorf("Published = %v; want %v", p.Published, want)
+	}
+	if _, off := p.OriginalPublished.Zone(); off != 9*60*60 || !p.OriginalPublished.Equal(want) {
+		t.Errorf("OriginalPublished = %v; want %v in +0900", p.OriginalPublished, want)
+	}
+	if p.Estimated {
+		t.Errorf("Estimated = true; want false")
+	}
+
+	loc := time.FixedZone("EST", -5*60*60)
+	feed, err = ParseWithOptions(strings.NewReader(s), &ParseOptions{TimeZone: loc})
+	if err != nil {
+		t.Fatalf("ParseWithOptions = %v", err)
+	}
+	if p := feed.Articles[0]; p.Published.Location() != loc || !p.Published.Equal(want) {
+		t.Errorf("Published = %v; want %v", p.Published, want.In(loc))
+	}
+}
+
+func TestParseDateW3CDTF(t *testing.T) {
+	tab := []struct {
+		s    string
+		want time.Time
+	}{
+		{s: "2015-01-01T10:00+09:00", want: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{s: "2015-01-01T10:00Z", want: time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{s: "2015-01", want: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "2015", want: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, v := range tab {
+		s := `<rdf:RDF xmlns="http://purl.org/rss/1.0/"
+			xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+			xmlns:dc="http://purl.org/dc/elements/1.1/">
+			<channel rdf:about="http://example.com/"><title>t</title></channel>
+			<item rdf:about="http://example.com/a"><title>a</title><dc:date>` + v.s + `</dc:date></item>
+		</rdf:RDF>`
+		feed, err := Parse(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", v.s, err)
+			continue
+		}
+		if p := feed.Articles[0]; !p.Published.Equal(v.want) || p.Estimated {
+			t.Errorf("Parse(%q).Published = %v; want %v", v.s, p.Published, v.want)
+		}
+	}
+}
+
+func TestParseDateEstimated(t *testing.T) {
+	fetched := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	seen := time.Date(2014, 12, 31, 0, 0, 0, 0, time.UTC)
+	tab := []struct {
+		s         string
+		firstSeen func(p *Article) time.Time
+		want      time.Time
+		estimated bool
+	}{
+		{s: `<pubDate>Thu, 01 Jan 1970 00:00:00 +0000</pubDate>`, want: fetched, estimated: true},
+		{s: `<pubDate>Sat, 01 Jan 2050 00:00:00 +0000</pubDate>`, want: fetched, estimated: true},
+		{s: ``, want: fetched, estimated: true},
+		{
+			s:         ``,
+			firstSeen: func(p *Article) time.Time { return seen },
+			want:      seen,
+			estimated: true,
+		},
+		{
+			s:    `<pubDate>Sat, 01 Jan 2050 00:00:00 +0000</pubDate><dc:modified xmlns:dc="http://purl.org/dc/terms/">2014-12-30T00:00:00Z</dc:modified>`,
+			want: time.Date(2014, 12, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, v := range tab {
+		s := rss2WithPubDate(v.s)
+		feed, err := ParseWithOptions(strings.NewReader(s), &ParseOptions{
+			FetchTime: fetched,
+			FirstSeen: v.firstSeen,
+		})
+		if err != nil {
+			t.Errorf("ParseWithOptions(%q) = %v", v.s, err)
+			continue
+		}
+		p := feed.Articles[0]
+		if !p.Published.Equal(v.want) {
+			t.Errorf("ParseWithOptions(%q).Published = %v; want %v", v.s, p.Published, v.want)
+		}
+		if p.Estimated != v.estimated {
+			t.Errorf("ParseWithOptions(%q).Estimated = %t; want %t", v.s, p.Estimated, v.estimated)
+		}
+	}
+}
+
+func TestParseDateLenient(t *testing.T) {
+	for _, s := range []string{`<pubDate>yesterday</pubDate>`, `<pubDate></pubDate>`} {
+		s := rss2WithPubDate(s)
+		if _, err := Parse(strings.NewReader(s)); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("Parse(%q) = %v; want %v", s, err, ErrInvalidDate)
+		}
+		feed, _, err := ParseLenient(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("ParseLenient(%q) = %v", s, err)
+			continue
+		}
+		if !feed.Articles[0].Estimated {
+			t.Errorf("ParseLenient(%q).Estimated = false; want true", s)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package news
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/rss1"
+	"github.com/lufia/news/rss2"
+)
+
+// Dialect is a format of feeds such as RSS 2.0 or Atom.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+
+	// MIMETypes returns media types of documents in the dialect.
+	MIMETypes() []string
+
+	// Detect reports whether the root element start is of the dialect.
+	Detect(start xml.StartElement) bool
+
+	// Parse decodes the rest of a document from d
+	// after its root element start was read.
+	// It returns a raw document specific to the dialect.
+	Parse(d *xml.Decoder, start *xml.StartElement) (raw interface{}, err error)
+
+	// Convert converts raw that was returned by Parse to *Feed.
+	Convert(raw interface{}) (*Feed, error)
+}
+
+// Dialects built in this package.
+var (
+	RSS1 Dialect = &xmlDialect{
+		name:      "rss1.0",
+		mimeTypes: []string{"application/rdf+xml", "application/rss+xml"},
+		elems: []distinctElement{
+			{XMLName: xml.Name{Space: rss1.RDFNamespace, Local: "RDF"}},
+		},
+		parse: func(d *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+			return rss1.DecodeElement(d, start)
+		},
+		convert: func(feed *Feed, raw interface{}) error {
+			p, ok := raw.(*rss1.Feed)
+			if !ok {
+				return errUnexpectedRaw(raw)
+			}
+			return feed.importRSS1(p)
+		},
+	}
+	RSS2 Dialect = &xmlDialect{
+		name:      "rss2.0",
+		mimeTypes: []string{"application/rss+xml"},
+		elems: []distinctElement{
+			{XMLName: xml.Name{Local: "rss"}, Version: "2.0"},
+		},
+		parse: func(d *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+			return rss2.DecodeElement(d, start)
+		},
+		convert: func(feed *Feed, raw interface{}) error {
+			p, ok := raw.(*rss2.Feed)
+			if !ok {
+				return errUnexpectedRaw(raw)
+			}
+			return feed.importRSS2(p)
+		},
+	}
+	Atom Dialect = &xmlDialect{
+		name:      "atom",
+		mimeTypes: []string{"application/atom+xml"},
+		elems: []distinctElement{
+			{XMLName: xml.Name{Space: atom.Namespace, Local: "feed"}},
+			{XMLName: xml.Name{Space: atom.Namespace03, Local: "feed"}},
+		},
+		parse: func(d *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+			return atom.DecodeElement(d, start)
+		},
+		convert: func(feed *Feed, raw interface{}) error {
+			p, ok := raw.(*atom.Feed)
+			if !ok {
+				return errUnexpectedRaw(raw)
+			}
+			return feed.importAtom(p)
+		},
+	}
+)
+
+func errUnexpectedRaw(raw interface{}) error {
+	return fmt.Errorf("unexpected raw document: %T", raw)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = []Dialect{RSS1, RSS2, Atom}
+)
+
+// saveDialects returns a function that restores registered dialects
+// to the current ones. It is used by tests.
+func saveDialects() (restore func()) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	saved := append([]Dialect(nil), dialects...)
+	return func() {
+		dialectsMu.Lock()
+		defer dialectsMu.Unlock()
+		dialects = saved
+	}
+}
+
+// RegisterDialect makes d available to Parse and DetectDialect.
+// Dialects are tried in the order of registration after built-in dialects.
+func RegisterDialect(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects = append(dialects, d)
+}
+
+type distinctElement struct {
+	XMLName xml.Name
+	Version string `xml:"version,attr"`
+}
+
+func (rule distinctElement) Match(v distinctElement) bool {
+	x1 := rule.XMLName
+	x2 := v.XMLName
+	if x1.Space != "" && x1.Space != x2.Space {
+		return false
+	}
+	if x1.Local != x2.Local {
+		return false
+	}
+	if rule.Version != "" && rule.Version != v.Version {
+		return false
+	}
+	return true
+}
+
+// newDistinctElement returns the distinctElement of the root element start.
+func newDistinctElement(start xml.StartElement) (x distinctElement) {
+	x.XMLName = start.Name
+	for _, a := range start.Attr {
+		if a.Name.Space == "" && a.Name.Local == "version" {
+			x.Version = a.Value
+		}
+	}
+	return
+}
+
+// xmlDialect is a Dialect that is distinguished by its root element.
+type xmlDialect struct {
+	name      string
+	mimeTypes []string
+	elems     []distinctElement
+	parse     func(d *xml.Decoder, start *xml.StartElement) (interface{}, error)
+	convert   func(feed *Feed, raw interface{}) error
+}
+
+func (x *xmlDialect) Name() string        { return x.name }
+func (x *xmlDialect) MIMETypes() []string { return x.mimeTypes }
+func (x *xmlDialect) String() string      { return x.name }
+
+func (x *xmlDialect) Detect(start xml.StartElement) bool {
+	v := newDistinctElement(start)
+	for _, rule := range x.elems {
+		if rule.Match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *xmlDialect) Parse(d *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+	return x.parse(d, start)
+}
+
+func (x *xmlDialect) Convert(raw interface{}) (*Feed, error) {
+	feed := &Feed{}
+	if err := x.convert(feed, raw); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// readRootElement reads tokens from d until the root element starts.
+func readRootElement(d *xml.Decoder) (start xml.StartElement, err error) {
+	for {
+		var tok xml.Token
+		tok, err = d.Token()
+		if err == io.EOF {
+			err = ErrUnknownDialect
+		}
+		if err != nil {
+			return
+		}
+		if v, ok := tok.(xml.StartElement); ok {
+			return v, nil
+		}
+	}
+}
+
+// DetectDialect reads the root element from r and returns its dialect.
+// It reads r within DefaultLimits.
+func DetectDialect(r io.Reader) (Dialect, error) {
+	return DetectDialectWithOptions(r, nil)
+}
+
+// DetectDialectWithOptions reads the root element from r with opts,
+// and returns its dialect. If opts is nil, it is the same as DetectDialect.
+func DetectDialectWithOptions(r io.Reader, opts *ParseOptions) (Dialect, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	if err := opts.context().Err(); err != nil {
+		return nil, err
+	}
+	d, _, err := opts.newDecoder(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	start, err := readRootElement(d)
+	if err != nil {
+		return nil, err
+	}
+	return detectDialect(start)
+}
+
+// detectDialect returns the dialect of the root element start.
+func detectDialect(start xml.StartElement) (Dialect, error) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	for _, d := range dialects {
+		if d.Detect(start) {
+			return d, nil
+		}
+	}
+	return nil, ErrUnknownDialect
+}
+
+// decode detects the dialect from the root element in d,
+// then decodes the rest of d with the dialect in a single pass.
+// Errors are reported at the position in t.
+func decode(d *xml.Decoder, t *pathTracker) (*Feed, error) {
+	start, err := readRootElement(d)
+	if err != nil {
+		return nil, t.wrap(nil, err)
+	}
+	dialect, err := detectDialect(start)
+	if err != nil {
+		return nil, t.wrap(nil, err)
+	}
+	raw, err := dialect.Parse(d, &start)
+	if err != nil {
+		return nil, t.wrap(dialect, err)
+	}
+	feed, err := dialect.Convert(raw)
+	if err != nil {
+		return nil, &ParseError{Dialect: dialect, Err: err}
+	}
+	feed.Dialect = dialect
+	feed.raw = raw
+	return feed, nil
+}
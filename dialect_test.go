@@ -0,0 +1,97 @@
+package news
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lufia/news/rss2"
+)
+
+type testDialect struct{}
+
+func (testDialect) Name() string        { return "test" }
+func (testDialect) MIMETypes() []string { return []string{"application/x-test+xml"} }
+
+func (testDialect) Detect(start xml.StartElement) bool {
+	return start.Name.Space == "urn:test" && start.Name.Local == "news"
+}
+
+type testDocument struct {
+	Title string   `xml:"title"`
+	Items []string `xml:"item"`
+}
+
+func (testDialect) Parse(d *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+	var x testDocument
+	if err := d.DecodeElement(&x, start); err != nil {
+		return nil, err
+	}
+	return &x, nil
+}
+
+func (testDialect) Convert(raw interface{}) (*Feed, error) {
+	x := raw.(*testDocument)
+	feed := &Feed{Title: x.Title}
+	for _, s := range x.Items {
+		feed.Articles = append(feed.Articles, &Article{Title: s})
+	}
+	return feed, nil
+}
+
+func TestRegisterDialect(t *testing.T) {
+	t.Cleanup(saveDialects())
+	RegisterDialect(testDialect{})
+	s := `<news xmlns="urn:test"><title>test</title><item>a</item><item>b</item></news>`
+	d, err := DetectDialect(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("DetectDialect = %v", err)
+	}
+	if d.Name() != "test" {
+		t.Errorf("DetectDialect = %v; want test", d.Name())
+	}
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	if feed.Title != "test" || len(feed.Articles) != 2 {
+		t.Errorf("Parse = %+v", feed)
+	}
+}
+
+func TestSaveDialects(t *testing.T) {
+	restore := saveDialects()
+	RegisterDialect(testDialect{})
+	restore()
+	s := `<news xmlns="urn:test"><title>test</title></news>`
+	if _, err := DetectDialect(strings.NewReader(s)); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("DetectDialect = %v; want %v", err, ErrUnknownDialect)
+	}
+}
+
+func TestFeedRaw(t *testing.T) {
+	s := `<rss version="2.0"><channel><title>test</title><ttl>30</ttl>
+		<item><title>a</title></item>
+	</channel></rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	if feed.Dialect != RSS2 {
+		t.Errorf("Dialect = %v; want %v", feed.Dialect, RSS2)
+	}
+	if p, ok := feed.Raw().(*rss2.Feed); !ok || p != feed.RSS2() || p.Channel.TTL != 30 {
+		t.Errorf("Raw() = %+v", feed.Raw())
+	}
+	if p := feed.Atom(); p != nil {
+		t.Errorf("Atom() = %+v; want nil", p)
+	}
+	if p := feed.RSS1(); p != nil {
+		t.Errorf("RSS1() = %+v; want nil", p)
+	}
+	item, ok := feed.Articles[0].Raw().(*rss2.Item)
+	if !ok || item.Title != "a" {
+		t.Errorf("Articles[0].Raw() = %+v", feed.Articles[0].Raw())
+	}
+}
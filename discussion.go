@@ -0,0 +1,77 @@
+package news
+
+import (
+	"strings"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/slash"
+	"github.com/lufia/news/thread"
+	"github.com/lufia/news/wfw"
+)
+
+// Discussion is comments and threading metadata of an article.
+type Discussion struct {
+	URL       string       // URL of the comments page
+	FeedURL   string       // URL of the comment feed
+	Count     int          // number of comments; zero if unknown
+	InReplyTo []*Reference // resources the article responds to
+}
+
+// Reference is a reference to the resource that an article responds to.
+type Reference struct {
+	ID     string
+	URL    string
+	Type   string
+	Source string
+}
+
+// discussion returns p.Discussion, it is allocated if p has no Discussion.
+func (p *Article) discussion() *Discussion {
+	if p.Discussion == nil {
+		p.Discussion = &Discussion{}
+	}
+	return p.Discussion
+}
+
+// importDiscussion fills p.Discussion with wfw, slash and thr extensions.
+func (p *Article) importDiscussion() {
+	if c, ok := p.Extension(wfw.Namespace).(*wfw.CommentAPI); ok && c.CommentRSS != "" {
+		p.discussion().FeedURL = c.CommentRSS
+	}
+	if s, ok := p.Extension(slash.Namespace).(*slash.Slash); ok && s.Comments > 0 {
+		p.discussion().Count = s.Comments
+	}
+	if t, ok := p.Extension(thread.Namespace).(*thread.Thread); ok {
+		if t.Total > 0 {
+			p.discussion().Count = t.Total
+		}
+		for _, r := range t.InReplyTo {
+			d := p.discussion()
+			d.InReplyTo = append(d.InReplyTo, &Reference{
+				ID:     r.Ref,
+				URL:    r.URL,
+				Type:   r.Type,
+				Source: r.Source,
+			})
+		}
+	}
+}
+
+// importAtomReplies fills p.Discussion with links that rel is replies.
+// A link to a HTML document is the comments page,
+// and others are assumed to be the comment feed.
+func (p *Article) importAtomReplies(links []atom.Link) {
+	for _, link := range links {
+		d := p.discussion()
+		if strings.HasPrefix(link.Type, "text/html") {
+			if d.URL == "" {
+				d.URL = link.URL
+			}
+		} else if d.FeedURL == "" {
+			d.FeedURL = link.URL
+		}
+		if d.Count == 0 && link.Count > 0 {
+			d.Count = link.Count
+		}
+	}
+}
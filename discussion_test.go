@@ -0,0 +1,132 @@
+package news
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lufia/news/slash"
+	"github.com/lufia/news/wfw"
+)
+
+func TestParseDiscussion(t *testing.T) {
+	tab := []struct {
+		XMLString string
+		Want      *Discussion
+	}{
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rss version="2.0"
+					xmlns:wfw="http://wellformedweb.org/CommentAPI/"
+					xmlns:slash="http://purl.org/rss/1.0/modules/slash/">
+					<channel>
+						<title>Example</title>
+						<item>
+							<link>http://example.com/1</link>
+							<comments>http://example.com/1#comments</comments>
+							<wfw:commentRss>http://example.com/1/feed</wfw:commentRss>
+							<slash:comments>3</slash:comments>
+						</item>
+					</channel>
+				</rss>`,
+			Want: &Discussion{
+				URL:     "http://example.com/1#comments",
+				FeedURL: "http://example.com/1/feed",
+				Count:   3,
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<feed xmlns="http://www.w3.org/2005/Atom"
+					xmlns:thr="http://purl.org/syndication/thread/1.0">
+					<title>Example</title>
+					<entry>
+						<id>tag:example.org,2005:1</id>
+						<link rel="replies" type="application/atom+xml"
+							href="http://example.org/1/replies" thr:count="10"/>
+						<link rel="replies" type="text/html" href="http://example.org/1#comments"/>
+						<thr:in-reply-to ref="tag:example.org,2005:0"
+							href="http://example.org/0" type="text/html"/>
+					</entry>
+				</feed>`,
+			Want: &Discussion{
+				URL:     "http://example.org/1#comments",
+				FeedURL: "http://example.org/1/replies",
+				Count:   10,
+				InReplyTo: []*Reference{
+					{ID: "tag:example.org,2005:0", URL: "http://example.org/0", Type: "text/html"},
+				},
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<feed xmlns="http://www.w3.org/2005/Atom"
+					xmlns:thr="http://purl.org/syndication/thread/1.0">
+					<title>Example</title>
+					<entry>
+						<id>tag:example.org,2005:1</id>
+						<link rel="replies" type="application/atom+xml"
+							href="http://example.org/1/replies" thr:count="many"/>
+					</entry>
+				</feed>`,
+			Want: &Discussion{
+				FeedURL: "http://example.org/1/replies",
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rss version="2.0">
+					<channel>
+						<title>Example</title>
+						<item><link>http://example.com/1</link></item>
+					</channel>
+				</rss>`,
+			Want: nil,
+		},
+	}
+	for _, v := range tab {
+		feed, err := Parse(strings.NewReader(v.XMLString))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", v.XMLString, err)
+			continue
+		}
+		d := feed.Articles[0].Discussion
+		if !reflect.DeepEqual(d, v.Want) {
+			t.Errorf("Parse(%q).Articles[0].Discussion = %+v; want %+v", v.XMLString, d, v.Want)
+		}
+	}
+}
+
+func TestParseBrokenExtension(t *testing.T) {
+	s := `<?xml version="1.0"?>
+		<rss version="2.0"
+			xmlns:wfw="http://wellformedweb.org/CommentAPI/"
+			xmlns:slash="http://purl.org/rss/1.0/modules/slash/">
+			<channel>
+				<title>Example</title>
+				<item>
+					<link>http://example.com/1</link>
+					<wfw:commentRss>http://example.com/1/feed</wfw:commentRss>
+					<slash:comments>n/a</slash:comments>
+				</item>
+			</channel>
+		</rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", s, err)
+	}
+	p := feed.Articles[0]
+	if v := p.Extension(slash.Namespace); v != nil {
+		t.Errorf("Extension(%q) = %v; want nil", slash.Namespace, v)
+	}
+	if err := p.ExtensionError(slash.Namespace); err == nil {
+		t.Errorf("ExtensionError(%q) = nil; want an error", slash.Namespace)
+	}
+	if err := p.ExtensionError(wfw.Namespace); err != nil {
+		t.Errorf("ExtensionError(%q) = %v", wfw.Namespace, err)
+	}
+	want := &Discussion{FeedURL: "http://example.com/1/feed"}
+	if !reflect.DeepEqual(p.Discussion, want) {
+		t.Errorf("Discussion = %+v; want %+v", p.Discussion, want)
+	}
+}
@@ -0,0 +1,54 @@
+// Package dublincore implements the Dublin Core metadata embedded in feeds.
+package dublincore
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/lufia/news/extension"
+)
+
+const (
+	// Namespace is the namespace of the Dublin Core Metadata Element Set.
+	Namespace = "http://purl.org/dc/elements/1.1/"
+
+	// TermsNamespace is the namespace of the DCMI Metadata Terms.
+	TermsNamespace = "http://purl.org/dc/terms/"
+)
+
+// Metadata is a set of Dublin Core elements attached to a channel or an item.
+// This package registers the decoder of Metadata for Namespace and
+// TermsNamespace to the extension package.
+type Metadata struct {
+	Creators     []string  `xml:"http://purl.org/dc/elements/1.1/ creator,omitempty"`
+	Contributors []string  `xml:"http://purl.org/dc/elements/1.1/ contributor,omitempty"`
+	Subjects     []string  `xml:"http://purl.org/dc/elements/1.1/ subject,omitempty"`
+	Publisher    string    `xml:"http://purl.org/dc/elements/1.1/ publisher,omitempty"`
+	Rights       string    `xml:"http://purl.org/dc/elements/1.1/ rights,omitempty"`
+	Language     string    `xml:"http://purl.org/dc/elements/1.1/ language,omitempty"`
+	Identifier   string    `xml:"http://purl.org/dc/elements/1.1/ identifier,omitempty"`
+	Date         time.Time `xml:"http://purl.org/dc/elements/1.1/ date,omitempty"`
+	Modified     time.Time `xml:"http://purl.org/dc/terms/ modified,omitempty"`
+}
+
+// IsZero returns true if m has no elements.
+func (m *Metadata) IsZero() bool {
+	return len(m.Creators) == 0 && len(m.Contributors) == 0 &&
+		len(m.Subjects) == 0 && m.Publisher == "" && m.Rights == "" &&
+		m.Language == "" && m.Identifier == "" &&
+		m.Date.IsZero() && m.Modified.IsZero()
+}
+
+func init() {
+	extension.Register(Namespace, decode)
+	extension.Register(TermsNamespace, decode)
+}
+
+// decode decodes Dublin Core elements to *Metadata.
+func decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var m Metadata
+	if err := d.DecodeElement(&m, &start); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,85 @@
+package dublincore
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lufia/news/extension"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type item struct {
+		Title string `xml:"title"`
+		Metadata
+	}
+	s := `<item xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">
+		<title>title</title>
+		<dc:creator>Alice</dc:creator>
+		<dc:creator>Bob</dc:creator>
+		<dc:contributor>Carol</dc:contributor>
+		<dc:subject>Go</dc:subject>
+		<dc:publisher>Example Inc.</dc:publisher>
+		<dc:rights>Copyright 2015 Example Inc.</dc:rights>
+		<dc:language>ja</dc:language>
+		<dc:identifier>urn:isbn:0000000000</dc:identifier>
+		<dc:date>2015-01-01T02:03:45+09:00</dc:date>
+		<dcterms:modified>2015-02-01T02:03:45Z</dcterms:modified>
+	</item>`
+	var x item
+	if err := xml.Unmarshal([]byte(s), &x); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	want := Metadata{
+		Creators:     []string{"Alice", "Bob"},
+		Contributors: []string{"Carol"},
+		Subjects:     []string{"Go"},
+		Publisher:    "Example Inc.",
+		Rights:       "Copyright 2015 Example Inc.",
+		Language:     "ja",
+		Identifier:   "urn:isbn:0000000000",
+		Date:         time.Date(2015, 1, 1, 2, 3, 45, 0, time.FixedZone("", 9*60*60)),
+		Modified:     time.Date(2015, 2, 1, 2, 3, 45, 0, time.UTC),
+	}
+	if x.Title != "title" {
+		t.Errorf("Title = %q; want %q", x.Title, "title")
+	}
+	if !reflect.DeepEqual(x.Metadata.Creators, want.Creators) {
+		t.Errorf("Creators = %v; want %v", x.Metadata.Creators, want.Creators)
+	}
+	if !x.Date.Equal(want.Date) || !x.Modified.Equal(want.Modified) {
+		t.Errorf("Date, Modified = %v, %v; want %v, %v", x.Date, x.Modified, want.Date, want.Modified)
+	}
+	x.Date, x.Modified = want.Date, want.Modified
+	if !reflect.DeepEqual(x.Metadata, want) {
+		t.Errorf("Metadata = %#v; want %#v", x.Metadata, want)
+	}
+	if x.IsZero() {
+		t.Errorf("IsZero() = true; want false")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	s := `<item xmlns:dc="http://purl.org/dc/elements/1.1/">
+		<dc:creator>Alice</dc:creator>
+		<dc:rights>Copyright 2015 Example Inc.</dc:rights>
+	</item>`
+	var v struct {
+		Extensions []extension.Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := extension.New(v.Extensions).Decode(Namespace)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", Namespace, err)
+	}
+	m, ok := p.(*Metadata)
+	if !ok {
+		t.Fatalf("Decode(%q) = %#v; want *Metadata", Namespace, p)
+	}
+	if !reflect.DeepEqual(m.Creators, []string{"Alice"}) || m.Rights != "Copyright 2015 Example Inc." {
+		t.Errorf("Decode(%q) = %#v", Namespace, m)
+	}
+}
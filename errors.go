@@ -0,0 +1,166 @@
+package news
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownDialect is the error that the document is not a feed of any known dialect.
+	ErrUnknownDialect = errors.New("news: unknown dialect")
+
+	// ErrSyntax is the error that the document is not well-formed XML.
+	ErrSyntax = errors.New("news: syntax error")
+
+	// ErrInvalidDate is the error that a date in the document can't be parsed.
+	ErrInvalidDate = errors.New("news: invalid date")
+)
+
+// ParseError records an error that was occurred while parsing a document.
+// It matches ErrSyntax or ErrInvalidDate with errors.Is
+// by the kind of the underlying error.
+type ParseError struct {
+	Dialect Dialect // nil if the dialect is not detected yet
+	Path    string  // path of the element, such as rss/channel/item[3]/pubDate
+	Line    int     // 1-based line number
+	Column  int     // 1-based column number
+	Offset  int64   // byte offset
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	b.WriteString("news: ")
+	if e.Dialect != nil {
+		b.WriteString(e.Dialect.Name())
+		b.WriteString(": ")
+	}
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		b.WriteString(": ")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", e.Line, e.Column)
+	}
+	// errors of this package, such as ErrUnknownDialect, have the prefix already.
+	b.WriteString(strings.TrimPrefix(e.Err.Error(), "news: "))
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ParseError) Is(target error) bool {
+	switch target {
+	case ErrSyntax:
+		var err *xml.SyntaxError
+		return errors.As(e.Err, &err)
+	case ErrInvalidDate:
+		var err *time.ParseError
+		return errors.As(e.Err, &err)
+	}
+	return false
+}
+
+// pathTracker is a xml.TokenReader that tracks the path and the position
+// of elements which is read from r, for reporting errors.
+type pathTracker struct {
+	r      xml.TokenReader
+	src    *xml.Decoder // decoder that reads bytes
+	stack  []pathElement
+	closed *pathElement // element that was closed by the last token; it points into stack
+}
+
+type pathElement struct {
+	name   string
+	index  int // 1-based index in siblings of the same name
+	line   int
+	column int
+	offset int64
+	counts map[string]int // number of children by name
+}
+
+// String returns the name of e with its index, such as item[3].
+func (e *pathElement) String() string {
+	if e.index > 1 {
+		return fmt.Sprintf("%s[%d]", e.name, e.index)
+	}
+	return e.name
+}
+
+func newPathTracker(r xml.TokenReader, src *xml.Decoder) *pathTracker {
+	return &pathTracker{r: r, src: src}
+}
+
+func (t *pathTracker) Token() (xml.Token, error) {
+	line, column := t.src.InputPos()
+	offset := t.src.InputOffset()
+	tok, err := t.r.Token()
+	if err != nil {
+		return nil, err
+	}
+	t.closed = nil
+	switch v := tok.(type) {
+	case xml.StartElement:
+		name := v.Name.Local
+		index := 1
+		if n := len(t.stack); n > 0 {
+			parent := &t.stack[n-1]
+			if parent.counts == nil {
+				parent.counts = make(map[string]int)
+			}
+			parent.counts[name]++
+			index = parent.counts[name]
+		}
+		t.stack = append(t.stack, pathElement{
+			name:   name,
+			index:  index,
+			line:   line,
+			column: column,
+			offset: offset,
+		})
+	case xml.EndElement:
+		if n := len(t.stack); n > 0 {
+			// the next push overwrites it, but closed is reset before that.
+			t.closed = &t.stack[n-1]
+			t.stack = t.stack[:n-1]
+		}
+	}
+	return tok, nil
+}
+
+func (t *pathTracker) path() string {
+	a := make([]string, 0, len(t.stack)+1)
+	for _, e := range t.stack {
+		a = append(a, e.String())
+	}
+	if t.closed != nil {
+		a = append(a, t.closed.String())
+	}
+	return strings.Join(a, "/")
+}
+
+// wrap returns a ParseError that wraps err with the current position.
+// If the last token closed an element, the error is reported at the element
+// because decoders of the element fail after reading its end.
+func (t *pathTracker) wrap(dialect Dialect, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := &ParseError{
+		Dialect: dialect,
+		Path:    t.path(),
+		Err:     err,
+	}
+	if t.closed != nil {
+		e.Line, e.Column, e.Offset = t.closed.line, t.closed.column, t.closed.offset
+	} else {
+		e.Line, e.Column = t.src.InputPos()
+		e.Offset = t.src.InputOffset()
+	}
+	return e
+}
@@ -0,0 +1,76 @@
+package news
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	s := `<?xml version="1.0"?>
+<rss version="2.0">
+	<channel>
+		<title>test</title>
+		<item><title>1</title></item>
+		<item><title>2</title></item>
+		<item>
+			<title>3</title>
+			<pubDate>yesterday</pubDate>
+		</item>
+	</channel>
+</rss>`
+	_, err := Parse(strings.NewReader(s))
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Fatalf("Parse = %v; want ParseError", err)
+	}
+	if !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("errors.Is(%v, ErrInvalidDate) = false", err)
+	}
+	if errors.Is(err, ErrSyntax) {
+		t.Errorf("errors.Is(%v, ErrSyntax) = true", err)
+	}
+	if e.Dialect != RSS2 {
+		t.Errorf("Dialect = %v; want %v", e.Dialect, RSS2)
+	}
+	if want := "rss/channel/item[3]/pubDate"; e.Path != want {
+		t.Errorf("Path = %q; want %q", e.Path, want)
+	}
+	if e.Line != 9 || e.Column != 4 {
+		t.Errorf("Line, Column = %d, %d; want 9, 4", e.Line, e.Column)
+	}
+	if want := int64(strings.Index(s, "<pubDate>")); e.Offset != want {
+		t.Errorf("Offset = %d; want %d", e.Offset, want)
+	}
+}
+
+func TestParseErrorKind(t *testing.T) {
+	tab := []struct {
+		s    string
+		want error
+		path string
+	}{
+		{`<?xml version="1.0"?>`, ErrUnknownDialect, ""},
+		{`<xhtml><body></body></xhtml>`, ErrUnknownDialect, "xhtml"},
+		{`<rss version="2.0"><channel><title>a</channel></rss>`, ErrSyntax, "rss/channel/title"},
+		{`<feed xmlns="http://www.w3.org/2005/Atom"><updated>now</updated></feed>`, ErrInvalidDate, "feed/updated"},
+	}
+	for _, v := range tab {
+		_, err := Parse(strings.NewReader(v.s))
+		if !errors.Is(err, v.want) {
+			t.Errorf("Parse(%q) = %v; want %v", v.s, err, v.want)
+			continue
+		}
+		var e *ParseError
+		if !errors.As(err, &e) {
+			t.Errorf("Parse(%q) = %v; want ParseError", v.s, err)
+			continue
+		}
+		if e.Path != v.path {
+			t.Errorf("Parse(%q).Path = %q; want %q", v.s, e.Path, v.path)
+		}
+		if msg := e.Error(); strings.Count(msg, "news:") != 1 {
+			t.Errorf("Parse(%q).Error() = %q; want one prefix", v.s, msg)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package news
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/dublincore"
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/syndication"
+)
+
+// RegisterExtension registers fn as the decoder for elements in the namespace ns.
+// Parse decodes elements in ns of each feed and article with fn,
+// then the value fn returned can be retrieved by Extension.
+// If fn returns an error, Parse continues and the error can be retrieved by ExtensionError.
+// Dublin Core is registered by default.
+func RegisterExtension(ns string, fn func(d *xml.Decoder, start xml.StartElement) (interface{}, error)) {
+	extension.Register(ns, fn)
+}
+
+// Extension returns the value decoded from elements in the namespace ns.
+// It returns nil if no decoder is registered for ns, the feed has no elements in ns,
+// or the decoder failed; see ExtensionError.
+func (feed *Feed) Extension(ns string) interface{} {
+	return feed.modules[ns]
+}
+
+// ExtensionError returns the error of the decoder for the namespace ns.
+// A failure of an extension does not make Parse fail.
+func (feed *Feed) ExtensionError(ns string) error {
+	return feed.moduleErrs[ns]
+}
+
+// Extension returns the value decoded from elements in the namespace ns.
+// It returns nil if no decoder is registered for ns, the article has no elements in ns,
+// or the decoder failed; see ExtensionError.
+func (p *Article) Extension(ns string) interface{} {
+	return p.modules[ns]
+}
+
+// ExtensionError returns the error of the decoder for the namespace ns.
+// A failure of an extension does not make Parse fail.
+func (p *Article) ExtensionError(ns string) error {
+	return p.moduleErrs[ns]
+}
+
+// decodeModules decodes x with registered decoders.
+// It returns decoded values and errors by namespace.
+func decodeModules(x extension.Extensions) (map[string]interface{}, map[string]error) {
+	m, err := x.DecodeAll()
+	errs, ok := err.(extension.DecodeErrors)
+	if !ok || len(errs) == 0 {
+		return m, nil
+	}
+	moduleErrs := make(map[string]error, len(errs))
+	for _, e := range errs {
+		moduleErrs[e.Namespace] = e.Err
+	}
+	return m, moduleErrs
+}
+
+var dublinCoreNamespaces = []string{
+	dublincore.Namespace,
+	dublincore.TermsNamespace,
+}
+
+func (feed *Feed) importExtensions(elems []extension.Element) {
+	feed.Extensions = extension.New(elems)
+	feed.modules, feed.moduleErrs = decodeModules(feed.Extensions)
+	for _, ns := range dublinCoreNamespaces {
+		if m, ok := feed.Extension(ns).(*dublincore.Metadata); ok {
+			feed.mergeDublinCore(m)
+		}
+	}
+	if u, ok := feed.Extension(syndication.Namespace).(*syndication.Update); ok {
+		feed.UpdatePolicy.importSyndication(u)
+	}
+	feed.Geo = importGeo(feed.modules)
+	feed.Paging.importExtensions(feed.Extensions)
+	feed.Links = append(feed.Links, importExtensionLinks(feed.Extensions)...)
+}
+
+func (p *Article) importExtensions(elems []extension.Element) {
+	p.Extensions = extension.New(elems)
+	p.modules, p.moduleErrs = decodeModules(p.Extensions)
+	for _, ns := range dublinCoreNamespaces {
+		if m, ok := p.Extension(ns).(*dublincore.Metadata); ok {
+			p.mergeDublinCore(m)
+		}
+	}
+	p.importDiscussion()
+	p.Geo = importGeo(p.modules)
+	p.Links = append(p.Links, importExtensionLinks(p.Extensions)...)
+}
+
+// mergeDublinCore fills fields with the Dublin Core metadata m.
+// Native elements of each dialect take precedence over Dublin Core;
+// m is used only for fields that native elements left empty.
+func (feed *Feed) mergeDublinCore(m *dublincore.Metadata) {
+	if feed.Copyright == "" {
+		feed.Copyright = m.Rights
+	}
+	if len(feed.Authors) == 0 {
+		feed.Authors = m.Creators
+	}
+	if feed.Publisher == "" {
+		feed.Publisher = m.Publisher
+	}
+	if feed.Language == "" {
+		feed.Language = m.Language
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = m.Modified
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = m.Date
+	}
+}
+
+// mergeDublinCore fills fields with the Dublin Core metadata m.
+// See Feed.mergeDublinCore for precedence.
+func (p *Article) mergeDublinCore(m *dublincore.Metadata) {
+	if p.ID == "" {
+		p.ID = m.Identifier
+	}
+	if len(p.Authors) == 0 {
+		p.Authors = m.Creators
+	}
+	p.Contributors = append(p.Contributors, m.Contributors...)
+	if p.Published.IsZero() {
+		p.Published = m.Date
+	}
+	if p.Updated.IsZero() {
+		p.Updated = m.Modified
+	}
+	p.Categories = append(p.Categories, m.Subjects...)
+	if p.Rights == "" {
+		p.Rights = m.Rights
+	}
+}
@@ -0,0 +1,302 @@
+// Package extension implements the elements that extend feed documents
+// with foreign namespaces.
+package extension
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Element is an XML element which the dialect does not know.
+type Element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Value    string     `xml:",chardata"`
+	Children []Element  `xml:",any"`
+}
+
+// Attr returns the value of the attribute named name.
+// Namespace of the attribute is ignored.
+func (e *Element) Attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Text returns the character data of e without leading and trailing spaces.
+func (e *Element) Text() string {
+	return strings.TrimSpace(e.Value)
+}
+
+// Extensions maps a namespace URI and a local name to elements.
+type Extensions map[string]map[string][]Element
+
+// New returns Extensions that contains elems.
+// It returns nil if elems is empty.
+func New(elems []Element) Extensions {
+	if len(elems) == 0 {
+		return nil
+	}
+	x := make(Extensions)
+	for _, e := range elems {
+		x.Add(e)
+	}
+	return x
+}
+
+// Add adds e to x.
+func (x Extensions) Add(e Element) {
+	m, ok := x[e.XMLName.Space]
+	if !ok {
+		m = make(map[string][]Element)
+		x[e.XMLName.Space] = m
+	}
+	m[e.XMLName.Local] = append(m[e.XMLName.Local], e)
+}
+
+// Get returns elements named local in the namespace ns.
+func (x Extensions) Get(ns, local string) []Element {
+	return x[ns][local]
+}
+
+// Value returns the text of the first element named local in the namespace ns.
+func (x Extensions) Value(ns, local string) string {
+	a := x.Get(ns, local)
+	if len(a) == 0 {
+		return ""
+	}
+	return a[0].Text()
+}
+
+// DecodeFunc decodes elements in a namespace to a typed value.
+// The start is a synthesized element named "extensions" in the namespace,
+// and its children are all the elements in the namespace
+// that are contained in a feed or an item.
+type DecodeFunc func(d *xml.Decoder, start xml.StartElement) (interface{}, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]DecodeFunc)
+)
+
+// Register registers fn as the decoder for the namespace ns.
+// If a decoder is already registered for ns, it is replaced.
+func Register(ns string, fn DecodeFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if fn == nil {
+		delete(registry, ns)
+		return
+	}
+	registry[ns] = fn
+}
+
+func lookup(ns string) DecodeFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[ns]
+}
+
+// Decode decodes elements in the namespace ns with the registered decoder.
+// It returns nil if no decoder is registered for ns or x has no elements in ns.
+func (x Extensions) Decode(ns string) (v interface{}, err error) {
+	fn := lookup(ns)
+	if fn == nil {
+		return nil, nil
+	}
+	m := x[ns]
+	if len(m) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(m))
+	for local := range m {
+		names = append(names, local)
+	}
+	sort.Strings(names)
+	start := xml.StartElement{Name: xml.Name{Space: ns, Local: "extensions"}}
+	tokens := []xml.Token{start}
+	for _, local := range names {
+		for _, e := range m[local] {
+			tokens = e.appendTokens(tokens)
+		}
+	}
+	tokens = append(tokens, start.End())
+
+	d := xml.NewTokenDecoder(&tokenReader{tokens: tokens})
+	if _, err = d.Token(); err != nil {
+		return
+	}
+	return fn(d, start)
+}
+
+// DecodeAll decodes elements in all namespaces that have registered decoder.
+// The result maps namespaces to decoded values. Namespaces that their decoder
+// failed are not contained in the result; DecodeAll returns the result with
+// DecodeErrors that holds an error for each of those namespaces.
+func (x Extensions) DecodeAll() (map[string]interface{}, error) {
+	var (
+		m    map[string]interface{}
+		errs DecodeErrors
+	)
+	for _, ns := range x.Namespaces() {
+		v, err := x.Decode(ns)
+		if err != nil {
+			errs = append(errs, &DecodeError{Namespace: ns, Err: err})
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]interface{})
+		}
+		m[ns] = v
+	}
+	if len(errs) > 0 {
+		return m, errs
+	}
+	return m, nil
+}
+
+// DecodeError records an error of the decoder for the namespace.
+type DecodeError struct {
+	Namespace string
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return "extension: " + e.Namespace + ": " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors is a list of errors that is returned by DecodeAll.
+type DecodeErrors []*DecodeError
+
+func (a DecodeErrors) Error() string {
+	s := make([]string, len(a))
+	for i, e := range a {
+		s[i] = e.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Namespaces returns namespaces in x in sorted order.
+func (x Extensions) Namespaces() []string {
+	a := make([]string, 0, len(x))
+	for ns := range x {
+		a = append(a, ns)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// appendTokens appends tokens of e to tokens.
+// Namespace declarations are dropped because names are already resolved.
+func (e *Element) appendTokens(tokens []xml.Token) []xml.Token {
+	start := xml.StartElement{Name: e.XMLName, Attr: e.Attrs}
+	for i, a := range e.Attrs {
+		if isNamespaceDecl(a.Name) {
+			start.Attr = make([]xml.Attr, 0, len(e.Attrs))
+			start.Attr = append(start.Attr, e.Attrs[:i]...)
+			for _, a := range e.Attrs[i+1:] {
+				if !isNamespaceDecl(a.Name) {
+					start.Attr = append(start.Attr, a)
+				}
+			}
+			break
+		}
+	}
+	tokens = append(tokens, start)
+	if e.Value != "" {
+		tokens = append(tokens, xml.CharData(e.Value))
+	}
+	for i := range e.Children {
+		tokens = e.Children[i].appendTokens(tokens)
+	}
+	return append(tokens, start.End())
+}
+
+func isNamespaceDecl(name xml.Name) bool {
+	return name.Space == "xmlns" || name.Space == "" && name.Local == "xmlns"
+}
+
+// tokenReader is a xml.TokenReader that reads tokens in order.
+type tokenReader struct {
+	tokens []xml.Token
+}
+
+func (r *tokenReader) Token() (xml.Token, error) {
+	if len(r.tokens) == 0 {
+		return nil, io.EOF
+	}
+	tok := r.tokens[0]
+	r.tokens = r.tokens[1:]
+	return tok, nil
+}
+
+// DecodeElement works like xml.Decoder.DecodeElement, except that
+// child elements of start that native reports false are not matched
+// to fields of v but are appended to exts.
+//
+// encoding/xml matches a field that has no namespace in its tag
+// to elements in any namespace; for example, a field tagged "comments"
+// would be overwritten by slash:comments. DecodeElement prevents it.
+func DecodeElement(d *xml.Decoder, v interface{}, start *xml.StartElement, native func(name xml.Name) bool, exts *[]Element) error {
+	r := &nativeReader{d: d, start: *start, native: native, exts: exts}
+	return xml.NewTokenDecoder(r).DecodeElement(v, nil)
+}
+
+// nativeReader is a xml.TokenReader that reads start, then tokens in start from d.
+// Child elements of start that native reports false are decoded
+// to exts instead of being returned.
+type nativeReader struct {
+	d      *xml.Decoder
+	start  xml.StartElement
+	native func(name xml.Name) bool
+	exts   *[]Element
+	depth  int // depth in start; zero before start is read
+	done   bool
+}
+
+func (r *nativeReader) Token() (xml.Token, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.depth == 0 {
+		r.depth = 1
+		return r.start, nil
+	}
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if r.depth == 1 && !r.native(t.Name) {
+				var e Element
+				if err := r.d.DecodeElement(&e, &t); err != nil {
+					return nil, err
+				}
+				*r.exts = append(*r.exts, e)
+				continue
+			}
+			r.depth++
+		case xml.EndElement:
+			r.depth--
+			if r.depth == 0 {
+				r.done = true
+			}
+		}
+		return tok, nil
+	}
+}
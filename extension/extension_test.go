@@ -0,0 +1,210 @@
+package extension
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	type item struct {
+		Title      string    `xml:"title"`
+		Extensions []Element `xml:",any"`
+	}
+	s := `<item xmlns:ex="http://example.com/ns">
+		<title>title</title>
+		<ex:rating scale="5">4</ex:rating>
+		<ex:rating scale="10">8</ex:rating>
+		<ex:meta><ex:key>k</ex:key></ex:meta>
+		<comments>http://example.com/comments</comments>
+	</item>`
+	var v item
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	x := New(v.Extensions)
+	const ns = "http://example.com/ns"
+	a := x.Get(ns, "rating")
+	if len(a) != 2 {
+		t.Fatalf("len(Get(%q, %q)) = %d; want 2", ns, "rating", len(a))
+	}
+	if s := a[1].Attr("scale"); s != "10" {
+		t.Errorf("Attr(%q) = %q; want %q", "scale", s, "10")
+	}
+	if s := x.Value(ns, "rating"); s != "4" {
+		t.Errorf("Value(%q, %q) = %q; want %q", ns, "rating", s, "4")
+	}
+	meta := x.Get(ns, "meta")
+	if len(meta) != 1 || len(meta[0].Children) != 1 || meta[0].Children[0].Text() != "k" {
+		t.Errorf("Get(%q, %q) = %v", ns, "meta", meta)
+	}
+	if s := x.Value("", "comments"); s != "http://example.com/comments" {
+		t.Errorf("Value(%q, %q) = %q", "", "comments", s)
+	}
+	if s := x.Value(ns, "title"); s != "" {
+		t.Errorf("Value(%q, %q) = %q; want empty", ns, "title", s)
+	}
+}
+
+func TestNewEmpty(t *testing.T) {
+	if x := New(nil); x != nil {
+		t.Errorf("New(nil) = %v; want nil", x)
+	}
+}
+
+type rating struct {
+	Scale int `xml:"scale,attr"`
+	Value int `xml:",chardata"`
+}
+
+func TestDecode(t *testing.T) {
+	const ns = "http://example.com/rating"
+	Register(ns, func(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+		var x struct {
+			Ratings []rating `xml:"http://example.com/rating rating"`
+		}
+		if err := d.DecodeElement(&x, &start); err != nil {
+			return nil, err
+		}
+		return x.Ratings, nil
+	})
+	defer Register(ns, nil)
+
+	s := `<item xmlns:r="http://example.com/rating" xmlns:ex="http://example.com/ns">
+		<r:rating scale="5">4</r:rating>
+		<r:rating scale="10">8</r:rating>
+		<ex:other>ignored</ex:other>
+	</item>`
+	var v struct {
+		Extensions []Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	x := New(v.Extensions)
+	p, err := x.Decode(ns)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", ns, err)
+	}
+	want := []rating{{Scale: 5, Value: 4}, {Scale: 10, Value: 8}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Decode(%q) = %v; want %v", ns, p, want)
+	}
+	if p, err := x.Decode("http://example.com/ns"); p != nil || err != nil {
+		t.Errorf("Decode of unregistered namespace = %v, %v; want nil, nil", p, err)
+	}
+	m, err := x.DecodeAll()
+	if err != nil {
+		t.Fatalf("DecodeAll() = %v", err)
+	}
+	if len(m) != 1 || !reflect.DeepEqual(m[ns], want) {
+		t.Errorf("DecodeAll() = %v", m)
+	}
+}
+
+func TestDecodeNested(t *testing.T) {
+	const ns = "urn:example:x"
+	type item struct {
+		Lang  string   `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Names []string `xml:"urn:example:x name"`
+	}
+	Register(ns, func(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+		var x struct {
+			Items []item `xml:"urn:example:x item"`
+		}
+		if err := d.DecodeElement(&x, &start); err != nil {
+			return nil, err
+		}
+		return x.Items, nil
+	})
+	defer Register(ns, nil)
+
+	s := `<entry><item xmlns="urn:example:x" xmlns:y="urn:example:y" xml:lang="en">
+		<name>a</name><y:name>ignored</y:name><name>b &amp; c</name>
+	</item></entry>`
+	var v struct {
+		Extensions []Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := New(v.Extensions).Decode(ns)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", ns, err)
+	}
+	want := []item{{Lang: "en", Names: []string{"a", "b & c"}}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Decode(%q) = %+v; want %+v", ns, p, want)
+	}
+}
+
+func TestDecodeAllError(t *testing.T) {
+	const (
+		ns1 = "http://example.com/valid"
+		ns2 = "http://example.com/invalid"
+	)
+	fn := func(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+		var v struct {
+			N int `xml:"n"`
+		}
+		if err := d.DecodeElement(&v, &start); err != nil {
+			return nil, err
+		}
+		return v.N, nil
+	}
+	Register(ns1, fn)
+	Register(ns2, fn)
+	defer Register(ns1, nil)
+	defer Register(ns2, nil)
+
+	x := New([]Element{
+		{XMLName: xml.Name{Space: ns1, Local: "n"}, Value: "1"},
+		{XMLName: xml.Name{Space: ns2, Local: "n"}, Value: "n/a"},
+	})
+	m, err := x.DecodeAll()
+	if !reflect.DeepEqual(m, map[string]interface{}{ns1: 1}) {
+		t.Errorf("DecodeAll() = %v", m)
+	}
+	errs, ok := err.(DecodeErrors)
+	if !ok || len(errs) != 1 || errs[0].Namespace != ns2 {
+		t.Errorf("DecodeAll() = %v; want an error of %q", err, ns2)
+	}
+}
+
+type nativeItem struct {
+	ID         string    `xml:"id,attr"`
+	Title      string    `xml:"title"`
+	Comments   string    `xml:"comments"`
+	Extensions []Element `xml:",any"`
+}
+
+func (item *nativeItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain nativeItem
+	native := func(name xml.Name) bool {
+		return name.Space == ""
+	}
+	return DecodeElement(d, (*plain)(item), &start, native, &item.Extensions)
+}
+
+func TestDecodeElement(t *testing.T) {
+	s := `<item id="1" xmlns:slash="http://purl.org/rss/1.0/modules/slash/">
+		<title>title</title>
+		<comments>http://example.com/comments</comments>
+		<slash:comments>3</slash:comments>
+		<unknown>u</unknown>
+	</item>`
+	var item nativeItem
+	if err := xml.Unmarshal([]byte(s), &item); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	if item.ID != "1" || item.Title != "title" || item.Comments != "http://example.com/comments" {
+		t.Errorf("Unmarshal(%q) = %+v", s, item)
+	}
+	x := New(item.Extensions)
+	if v := x.Value("http://purl.org/rss/1.0/modules/slash/", "comments"); v != "3" {
+		t.Errorf("slash:comments = %q; want %q", v, "3")
+	}
+	if v := x.Value("", "unknown"); v != "u" {
+		t.Errorf("unknown = %q; want %q", v, "u")
+	}
+}
@@ -2,212 +2,203 @@
 package news
 
 import (
-	"bytes"
-	"encoding/xml"
-	"errors"
+	"crypto/sha1"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"time"
-	"unicode/utf8"
 
 	"github.com/lufia/news/atom"
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/geo"
 	"github.com/lufia/news/rss1"
 	"github.com/lufia/news/rss2"
 )
 
-type distinctElement struct {
-	XMLName xml.Name
-	Version string `xml:"version,attr"`
-}
+type Feed struct {
+	Title     string
+	URL       string
+	Links     []*Link
+	Summary   string
+	Image     *Image
+	Copyright string
+	Generator string
+	Editor    string
+	Authors   []string
+	Publisher string
+	Language  string
+	Updated   time.Time
+	Articles  []*Article
 
-func (rule distinctElement) Match(v distinctElement) bool {
-	x1 := rule.XMLName
-	x2 := v.XMLName
-	if x1.Space != "" && x1.Space != x2.Space {
-		return false
-	}
-	if x1.Local != x2.Local {
-		return false
-	}
-	if rule.Version != "" && rule.Version != v.Version {
-		return false
-	}
-	return true
-}
+	// Dialect is the dialect of the document that the feed was parsed from.
+	Dialect Dialect
 
-type Dialect struct {
-	Type  string
-	Parse func(r io.Reader) (feed interface{}, err error)
-}
+	// Fetched is the time when the document was fetched.
+	Fetched time.Time
 
-var (
-	rss1Dialect = &Dialect{
-		Type: "rss1.0",
-		Parse: func(r io.Reader) (feed interface{}, err error) {
-			return rss1.Parse(r)
-		},
-	}
-	rss2Dialect = &Dialect{
-		Type: "rss2.0",
-		Parse: func(r io.Reader) (feed interface{}, err error) {
-			return rss2.Parse(r)
-		},
-	}
-	atomDialect = &Dialect{
-		Type: "atom",
-		Parse: func(r io.Reader) (feed interface{}, err error) {
-			return atom.Parse(r)
-		},
-	}
-)
+	UpdatePolicy UpdatePolicy
+	Geo          *geo.Location
+	Paging       Paging
+
+	// Deleted holds articles that the publisher has removed from the feed.
+	Deleted []*Deletion
 
-var decisionTable = []struct {
-	elem    distinctElement
-	dialect *Dialect
-}{
-	{
-		elem: distinctElement{
-			XMLName: xml.Name{
-				Space: "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
-				Local: "RDF",
-			},
-		},
-		dialect: rss1Dialect,
-	},
-	{
-		elem: distinctElement{
-			XMLName: xml.Name{
-				Local: "rss",
-			},
-			Version: "2.0",
-		},
-		dialect: rss2Dialect,
-	},
-	{
-		elem: distinctElement{
-			XMLName: xml.Name{
-				Space: "http://www.w3.org/2005/Atom",
-				Local: "feed",
-			},
-		},
-		dialect: atomDialect,
-	},
-	{
-		elem: distinctElement{
-			XMLName: xml.Name{
-				Space: "http://purl.org/atom/ns#",
-				Local: "feed",
-			},
-		},
-		dialect: atomDialect,
-	},
+	// Extensions holds elements that are not known by the dialect.
+	// atom:link elements of RSS 2.0 channels are not in it but in Links,
+	// so Extension(atom.Namespace) doesn't return them.
+	Extensions extension.Extensions
+	modules    map[string]interface{}
+	moduleErrs map[string]error
+	raw        interface{}
 }
 
-var (
-	errUnknownDialect = errors.New("unknown dialect")
-)
+// Raw returns the document that feed was converted from.
+// It is the value returned by Parse of feed.Dialect,
+// for example *rss2.Feed for RSS 2.0.
+func (feed *Feed) Raw() interface{} {
+	return feed.raw
+}
 
-func DetectDialect(r io.Reader) (*Dialect, error) {
-	var x distinctElement
-	d := xml.NewDecoder(r)
-	if err := d.Decode(&x); err != nil {
-		return nil, err
-	}
-	for _, v := range decisionTable {
-		if v.elem.Match(x) {
-			return v.dialect, nil
-		}
-	}
-	return nil, errUnknownDialect
+// RSS1 returns the RSS 1.0 document that feed was converted from.
+// It returns nil if feed was not parsed from RSS 1.0.
+func (feed *Feed) RSS1() *rss1.Feed {
+	p, _ := feed.raw.(*rss1.Feed)
+	return p
 }
 
-// Cleanup discards invalid chars in XML 1.0.
-func Cleanup(p []byte) []byte {
-	tab := []rune{'\v'}
-	for _, c := range tab {
-		n := utf8.RuneLen(c)
-		for {
-			i := bytes.IndexRune(p, c)
-			if i < 0 {
-				break
-			}
-			copy(p[i:], p[i+n:])
-			p = p[:len(p)-n]
-		}
-	}
+// RSS2 returns the RSS 2.0 document that feed was converted from.
+// It returns nil if feed was not parsed from RSS 2.0.
+func (feed *Feed) RSS2() *rss2.Feed {
+	p, _ := feed.raw.(*rss2.Feed)
 	return p
 }
 
-func parse(r io.Reader) (feed interface{}, err error) {
-	buf, err := ioutil.ReadAll(r)
-	if err != nil {
-		return
-	}
-	buf = Cleanup(buf)
-	fin := bytes.NewReader(buf)
-	d, err := DetectDialect(fin)
-	if err != nil {
-		return
-	}
-	_, err = fin.Seek(0, 0)
-	if err != nil {
-		return
-	}
-	return d.Parse(fin)
+// Atom returns the Atom document that feed was converted from.
+// It returns nil if feed was not parsed from Atom.
+func (feed *Feed) Atom() *atom.Feed {
+	p, _ := feed.raw.(*atom.Feed)
+	return p
 }
 
-type Feed struct {
-	Title    string
-	URL      string
-	Summary  string
-	Articles []*Article
+type Image struct {
+	URL    string
+	Title  string
+	Link   string
+	Width  int
+	Height int
 }
 
 type Article struct {
-	Title      string
-	ID         string
-	URL        string
-	Authors    []string
-	Published  time.Time
-	Categories []string
-	Content    string
+	Title        string
+	ID           string
+	URL          string
+	Links        []*Link
+	Permalink    string
+	Authors      []string
+	Contributors []string
+	Published    time.Time
+	Updated      time.Time
+	Categories   []string
+	Rights       string
+	Content      string
+	Discussion   *Discussion
+	Geo          *geo.Location
+
+	// OriginalPublished and OriginalUpdated are dates as written in the
+	// document, with their own time zones. Published and Updated are
+	// normalized to the time zone of ParseOptions.
+	OriginalPublished time.Time
+	OriginalUpdated   time.Time
+
+	// Estimated reports whether Published is estimated because
+	// the document has no valid dates of the article.
+	Estimated bool
+
+	// Extensions holds elements that are not known by the dialect.
+	Extensions extension.Extensions
+	modules    map[string]interface{}
+	moduleErrs map[string]error
+	raw        interface{}
+}
+
+// Raw returns the element that p was converted from;
+// it is *rss1.Item, *rss2.Item or *atom.Entry for built-in dialects.
+// It returns nil if the dialect does not provide it.
+func (p *Article) Raw() interface{} {
+	return p.raw
+}
+
+// Deletion is a notice that the article identified by ID was deleted.
+type Deletion struct {
+	ID      string
+	When    time.Time
+	By      string
+	Comment string
+	URL     string
 }
 
+// Parse parses a feed of any dialect from r within DefaultLimits.
 func Parse(r io.Reader) (feed *Feed, err error) {
-	p, err := parse(r)
-	if err != nil {
-		return
+	return ParseWithOptions(r, nil)
+}
+
+// syntheticID returns a stable identifier derived from the article
+// for items that have no identifier of their own.
+func (p *Article) syntheticID() string {
+	h := sha1.New()
+	for _, s := range []string{p.Title, p.URL, p.Published.UTC().Format(time.RFC3339Nano), p.Content} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
 	}
-	feed = &Feed{}
-	switch v := p.(type) {
-	case *rss1.Feed:
-		err = feed.ImportFromRSS1(v)
-		return
-	case *rss2.Feed:
-		err = feed.ImportFromRSS2(v)
-		return
-	case *atom.Feed:
-		err = feed.ImportFromAtom(v)
-		return
-	default:
-		return nil, errors.New("unknown feed type")
+	return fmt.Sprintf("urn:sha1:%x", h.Sum(nil))
+}
+
+// ImportFromRSS1 converts the RSS 1.0 document r to feed, then applies opts
+// like ParseWithOptions. If opts is nil, the defaults are applied.
+// Options about decoding documents such as Location are not applied;
+// see ParseOptions.NewDecoder for them.
+func (feed *Feed) ImportFromRSS1(r *rss1.Feed, opts *ParseOptions) error {
+	if err := feed.importRSS1(r); err != nil {
+		return err
 	}
+	return feed.applyOptions(opts)
 }
 
-func (feed *Feed) ImportFromRSS1(r *rss1.Feed) (err error) {
-	feed.Title = r.Channel.Title
-	feed.URL = r.Channel.Link
-	feed.Summary = r.Channel.Description
+func (feed *Feed) importRSS1(r *rss1.Feed) (err error) {
+	feed.raw = r
+	x := r.Extensions
+	if c := r.Channel; c != nil {
+		feed.Title = c.Title
+		feed.URL = c.Link
+		feed.Links = alternateLink(c.Link)
+		feed.Summary = c.Description
+		x = append(x[:len(x):len(x)], c.Extensions...)
+	}
+	feed.importExtensions(x)
+	if r.Image != nil {
+		feed.Image = &Image{
+			URL:   r.Image.URL,
+			Title: r.Image.Title,
+			Link:  r.Image.Link,
+		}
+	}
 	feed.Articles = make([]*Article, len(r.Items))
 	for i, item := range r.Items {
 		p := &Article{
+			raw:       item,
 			Title:     item.Title,
-			ID:        r.Channel.Indexes[i].URL,
+			ID:        item.About,
 			URL:       item.Link,
-			Authors:   []string{item.Creator},
-			Published: item.Date,
+			Links:     alternateLink(item.Link),
+			Permalink: item.Link,
 			Content:   item.Description,
 		}
+		if p.ID == "" && r.Channel != nil && i < len(r.Channel.Indexes) {
+			p.ID = r.Channel.Indexes[i].URL
+		}
+		p.importExtensions(item.Extensions)
+		if p.ID == "" {
+			p.ID = p.syntheticID()
+		}
 		feed.Articles[i] = p
 	}
 	return nil
@@ -226,40 +217,132 @@ func (v *rss2Item) Published() time.Time {
 	return time.Time(v.PubDate)
 }
 
-func (feed *Feed) ImportFromRSS2(r *rss2.Feed) (err error) {
-	feed.Title = r.Channel.Title
-	feed.URL = r.Channel.Link
-	feed.Summary = r.Channel.Description
-	feed.Articles = make([]*Article, len(r.Channel.Items))
-	for i, item := range r.Channel.Items {
+func (v *rss2Item) CategoryNames() []string {
+	a := make([]string, len(v.Categories))
+	for i, c := range v.Categories {
+		a[i] = c.Content
+	}
+	return a
+}
+
+// ImportFromRSS2 converts the RSS 2.0 document r to feed, then applies opts
+// like ImportFromRSS1.
+func (feed *Feed) ImportFromRSS2(r *rss2.Feed, opts *ParseOptions) error {
+	if err := feed.importRSS2(r); err != nil {
+		return err
+	}
+	return feed.applyOptions(opts)
+}
+
+func (feed *Feed) importRSS2(r *rss2.Feed) (err error) {
+	feed.raw = r
+	c := r.Channel
+	if c == nil {
+		c = &rss2.Channel{}
+	}
+	feed.Title = c.Title
+	feed.URL = c.Link
+	feed.Links = append(alternateLink(c.Link), importAtomLinks(c.AtomLinks)...)
+	feed.Summary = c.Description
+	if img := c.Image; img != nil {
+		feed.Image = &Image{
+			URL:    img.URL,
+			Title:  img.Title,
+			Link:   img.Link,
+			Width:  img.Width,
+			Height: img.Height,
+		}
+	}
+	feed.Copyright = c.Copyright
+	feed.Generator = c.Generator
+	feed.Editor = c.ManagingEditor
+	feed.UpdatePolicy.importRSS2(c)
+	feed.Paging.importAtom(c.AtomLinks)
+	feed.Language = c.Language
+	feed.Updated = time.Time(c.LastBuildDate)
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Time(c.PubDate)
+	}
+	feed.importExtensions(c.Extensions)
+	feed.Articles = make([]*Article, len(c.Items))
+	for i, item := range c.Items {
 		v := (*rss2Item)(item)
 		p := &Article{
-			Title:     item.Title,
-			URL:       item.Link,
-			Authors:   v.Authors(),
-			Published: v.Published(),
-			Content:   item.Content(),
+			raw:        item,
+			Title:      item.Title,
+			URL:        item.Link,
+			Links:      importRSS2ItemLinks(item),
+			Permalink:  item.Permalink(),
+			Authors:    v.Authors(),
+			Published:  v.Published(),
+			Categories: v.CategoryNames(),
+			Content:    item.Content(),
 		}
-		if p.ID, err = item.ID(); err != nil {
-			return
+		p.ID, _ = item.ID()
+		p.importExtensions(item.Extensions)
+		if item.Comments != "" {
+			p.discussion().URL = item.Comments
+		}
+		if p.ID == "" {
+			p.ID = p.syntheticID()
 		}
 		feed.Articles[i] = p
 	}
-	return
+	return nil
 }
 
-func (feed *Feed) ImportFromAtom(r *atom.Feed) (err error) {
+// ImportFromAtom converts the Atom document r to feed, then applies opts
+// like ImportFromRSS1.
+func (feed *Feed) ImportFromAtom(r *atom.Feed, opts *ParseOptions) error {
+	if err := feed.importAtom(r); err != nil {
+		return err
+	}
+	return feed.applyOptions(opts)
+}
+
+func (feed *Feed) importAtom(r *atom.Feed) (err error) {
+	feed.raw = r
 	feed.Title = r.Title.Content
 	feed.URL = r.AlternateURL()
+	feed.Links = importAtomLinks(r.Links)
 	feed.Summary = r.Summary
+	if url := r.ImageURL(); url != "" {
+		feed.Image = &Image{URL: url}
+	}
+	feed.Copyright = r.Rights.Content
+	if r.Generator != nil {
+		feed.Generator = r.Generator.Name
+	}
+	feed.Authors = feed.atomAuthors(r.Authors)
+	feed.Paging.importAtom(r.Links)
+	feed.Updated = r.Updated
+	feed.importExtensions(r.Extensions)
+	for _, entry := range r.DeletedEntries {
+		p := &Deletion{
+			ID:      entry.Ref,
+			When:    entry.When,
+			Comment: entry.Comment.Content,
+			URL:     entry.AlternateURL(),
+		}
+		if entry.By != nil {
+			p.By = entry.By.Name
+		}
+		feed.Deleted = append(feed.Deleted, p)
+	}
 	feed.Articles = make([]*Article, len(r.Entries))
 	for i, entry := range r.Entries {
 		p := &Article{
-			Title:     entry.Title.Content,
-			ID:        entry.ID,
-			URL:       entry.AlternateURL(),
-			Authors:   feed.atomAuthors(entry.Authors),
-			Published: entry.PublishedTime(),
+			raw:        entry,
+			Title:      entry.Title.Content,
+			ID:         entry.ID,
+			URL:        entry.AlternateURL(),
+			Links:      importAtomLinks(entry.Links),
+			Permalink:  entry.AlternateURL(),
+			Authors:    feed.atomAuthors(entry.Authors),
+			Published:  entry.PublishedTime(),
+			Updated:    entry.UpdatedTime(),
+			Categories: feed.atomCategories(entry.Categories),
+			Rights:     entry.Rights.Content,
 		}
 		var s string
 		s, err = entry.Content.HTML()
@@ -267,6 +350,11 @@ func (feed *Feed) ImportFromAtom(r *atom.Feed) (err error) {
 			return
 		}
 		p.Content = s
+		p.importExtensions(entry.Extensions)
+		p.importAtomReplies(entry.Replies())
+		if p.ID == "" {
+			p.ID = p.syntheticID()
+		}
 		feed.Articles[i] = p
 	}
 	return
@@ -279,3 +367,11 @@ func (feed *Feed) atomAuthors(authors []atom.Person) []string {
 	}
 	return a
 }
+
+func (feed *Feed) atomCategories(categories []atom.Category) []string {
+	a := make([]string, len(categories))
+	for i, c := range categories {
+		a[i] = c.Term
+	}
+	return a
+}
@@ -1,26 +1,33 @@
 package news
 
 import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/lufia/news/dublincore"
 )
 
 func TestDetectDialect(t *testing.T) {
 	tab := []struct {
 		xml  string
-		want *Dialect
+		want Dialect
 	}{
 		{
 			xml: `<?xml version="1.0"?>
 				<feed xmlns="http://www.w3.org/2005/Atom">
 				</feed>`,
-			want: atomDialect,
+			want: Atom,
 		},
 		{
 			xml: `<?xml version="1.0"?>
 				<feed version="0.3" xmlns="http://purl.org/atom/ns#" xmlns:dc="http://purl.org/dc/elements/1.1/">
 				</feed>`,
-			want: atomDialect,
+			want: Atom,
 		},
 		{
 			xml: `<?xml version="1.0"?>
@@ -30,13 +37,13 @@ func TestDetectDialect(t *testing.T) {
 					xmlns:content="http://purl.org/rss/1.0/modules/content/"
 					xml:lang="ja">
 				</rdf:RDF>`,
-			want: rss1Dialect,
+			want: RSS1,
 		},
 		{
 			xml: `<?xml version="1.0"?>
 				<rss version="2.0">
 				</rss>`,
-			want: rss2Dialect,
+			want: RSS2,
 		},
 	}
 	for _, v := range tab {
@@ -52,22 +59,326 @@ func TestDetectDialect(t *testing.T) {
 	}
 }
 
-func TestCleanup(t *testing.T) {
-	tab := []struct {
-		s    string
-		want string
-	}{
-		{s: "abc", want: "abc"},
-		{s: "abc\v", want: "abc"},
-		{s: "\vabc", want: "abc"},
-		{s: "\va\vb\vc\v", want: "abc"},
-		{s: "\v\v", want: ""},
+func TestParseRSS2Channel(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<rss version="2.0">
+			<channel>
+				<title>Example</title>
+				<link>http://example.com/</link>
+				<description>Example channel</description>
+				<copyright>Copyright 2008 Example</copyright>
+				<managingEditor>editor@example.com</managingEditor>
+				<generator>Example Generator 1.0</generator>
+				<image>
+					<url>http://example.com/logo.png</url>
+					<title>Example</title>
+					<link>http://example.com/</link>
+				</image>
+			</channel>
+		</rss>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
 	}
-	for _, v := range tab {
-		r := Cleanup([]byte(v.s))
-		s := string(r)
-		if s != v.want {
-			t.Errorf("Cleanup(%q) = %q; want %q", v.s, s, v.want)
+	if feed.Copyright != "Copyright 2008 Example" {
+		t.Errorf("Copyright = %q", feed.Copyright)
+	}
+	if feed.Generator != "Example Generator 1.0" {
+		t.Errorf("Generator = %q", feed.Generator)
+	}
+	if feed.Editor != "editor@example.com" {
+		t.Errorf("Editor = %q", feed.Editor)
+	}
+	if feed.Image == nil || feed.Image.URL != "http://example.com/logo.png" {
+		t.Errorf("Image = %v", feed.Image)
+	}
+}
+
+func TestParseRSS2ContentEncoded(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+			<channel>
+				<title>Example</title>
+				<item>
+					<title>Article</title>
+					<description>summary</description>
+					<content:encoded><![CDATA[<p>full content</p>]]></content:encoded>
+				</item>
+			</channel>
+		</rss>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if s := feed.Articles[0].Content; s != "<p>full content</p>" {
+		t.Errorf("Content = %q; want %q", s, "<p>full content</p>")
+	}
+}
+
+func TestParseSyntheticID(t *testing.T) {
+	const s = `<?xml version="1.0"?>
+		<rss version="2.0">
+			<channel>
+				<title>Example</title>
+				<item>
+					<title>no identifier</title>
+					<description>content</description>
+				</item>
+			</channel>
+		</rss>`
+	feed1, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	feed2, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	id1 := feed1.Articles[0].ID
+	id2 := feed2.Articles[0].ID
+	if id1 == "" {
+		t.Errorf("ID is empty")
+	}
+	if id1 != id2 {
+		t.Errorf("ID = %q, %q; want same value", id1, id2)
+	}
+}
+
+func TestParseDublinCore(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<rss version="2.0"
+			xmlns:dc="http://purl.org/dc/elements/1.1/"
+			xmlns:dcterms="http://purl.org/dc/terms/">
+			<channel>
+				<title>Example</title>
+				<language>en</language>
+				<dc:language>ja</dc:language>
+				<dc:rights>Copyright 2015 Example</dc:rights>
+				<dc:publisher>Example Inc.</dc:publisher>
+				<item>
+					<title>article</title>
+					<author>author@example.com</author>
+					<dc:creator>Alice</dc:creator>
+					<dc:contributor>Bob</dc:contributor>
+					<dc:subject>Go</dc:subject>
+					<dc:identifier>urn:example:1</dc:identifier>
+					<dc:date>2015-01-01T02:03:45Z</dc:date>
+					<dcterms:modified>2015-02-01T02:03:45Z</dcterms:modified>
+				</item>
+			</channel>
+		</rss>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if feed.Language != "en" {
+		t.Errorf("Language = %q; want %q", feed.Language, "en")
+	}
+	if feed.Copyright != "Copyright 2015 Example" {
+		t.Errorf("Copyright = %q", feed.Copyright)
+	}
+	if feed.Publisher != "Example Inc." {
+		t.Errorf("Publisher = %q", feed.Publisher)
+	}
+	p := feed.Articles[0]
+	if !reflect.DeepEqual(p.Authors, []string{"author@example.com"}) {
+		t.Errorf("Authors = %v", p.Authors)
+	}
+	if !reflect.DeepEqual(p.Contributors, []string{"Bob"}) {
+		t.Errorf("Contributors = %v", p.Contributors)
+	}
+	if !reflect.DeepEqual(p.Categories, []string{"Go"}) {
+		t.Errorf("Categories = %v", p.Categories)
+	}
+	if p.ID != "urn:example:1" {
+		t.Errorf("ID = %q", p.ID)
+	}
+	if want := time.Date(2015, 1, 1, 2, 3, 45, 0, time.UTC); !p.Published.Equal(want) {
+		t.Errorf("Published = %v; want %v", p.Published, want)
+	}
+	if want := time.Date(2015, 2, 1, 2, 3, 45, 0, time.UTC); !p.Updated.Equal(want) {
+		t.Errorf("Updated = %v; want %v", p.Updated, want)
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	const ns = "http://example.com/ns"
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<feed xmlns="http://www.w3.org/2005/Atom" xmlns:ex="http://example.com/ns">
+			<title>Example</title>
+			<ex:owner id="1">Alice</ex:owner>
+			<entry>
+				<id>urn:example:1</id>
+				<title>article</title>
+				<ex:rating scale="5">4</ex:rating>
+			</entry>
+		</feed>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if s := feed.Extensions.Value(ns, "owner"); s != "Alice" {
+		t.Errorf("Feed.Extensions.Value(%q, %q) = %q; want %q", ns, "owner", s, "Alice")
+	}
+	a := feed.Articles[0].Extensions.Get(ns, "rating")
+	if len(a) != 1 || a[0].Text() != "4" || a[0].Attr("scale") != "5" {
+		t.Errorf("Article.Extensions.Get(%q, %q) = %v", ns, "rating", a)
+	}
+}
+
+type exampleMeta struct {
+	Owner string `xml:"http://example.com/meta owner"`
+}
+
+func TestRegisterExtension(t *testing.T) {
+	const ns = "http://example.com/meta"
+	RegisterExtension(ns, func(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+		var m exampleMeta
+		if err := d.DecodeElement(&m, &start); err != nil {
+			return nil, err
+		}
+		return &m, nil
+	})
+	defer RegisterExtension(ns, nil)
+
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<rdf:RDF xmlns="http://purl.org/rss/1.0/"
+			xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+			xmlns:dc="http://purl.org/dc/elements/1.1/"
+			xmlns:m="http://example.com/meta">
+			<channel rdf:about="http://example.com/rss">
+				<title>Example</title>
+			</channel>
+			<item rdf:about="http://example.com/1">
+				<title>article</title>
+				<dc:creator>Alice</dc:creator>
+				<m:owner>team-a</m:owner>
+			</item>
+		</rdf:RDF>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	p := feed.Articles[0]
+	m, ok := p.Extension(ns).(*exampleMeta)
+	if !ok {
+		t.Fatalf("Extension(%q) = %#v; want *exampleMeta", ns, p.Extension(ns))
+	}
+	if m.Owner != "team-a" {
+		t.Errorf("Owner = %q; want %q", m.Owner, "team-a")
+	}
+	dc, ok := p.Extension(dublincore.Namespace).(*dublincore.Metadata)
+	if !ok || !reflect.DeepEqual(dc.Creators, []string{"Alice"}) {
+		t.Errorf("Extension(%q) = %#v", dublincore.Namespace, p.Extension(dublincore.Namespace))
+	}
+	if !reflect.DeepEqual(p.Authors, []string{"Alice"}) {
+		t.Errorf("Authors = %v", p.Authors)
+	}
+}
+
+func TestParseDeleted(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<feed xmlns="http://www.w3.org/2005/Atom" xmlns:at="http://purl.org/atompub/tombstones/1.0">
+			<title>Example</title>
+			<at:deleted-entry ref="tag:example.org,2005:/entries/1" when="2005-11-29T12:11:12Z">
+				<at:by><name>John Doe</name></at:by>
+				<at:comment>removed</at:comment>
+				<link href="http://example.org/entries/1"/>
+			</at:deleted-entry>
+		</feed>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []*Deletion{
+		{
+			ID:      "tag:example.org,2005:/entries/1",
+			When:    time.Date(2005, 11, 29, 12, 11, 12, 0, time.UTC),
+			By:      "John Doe",
+			Comment: "removed",
+			URL:     "http://example.org/entries/1",
+		},
+	}
+	if !reflect.DeepEqual(feed.Deleted, want) {
+		t.Errorf("Deleted = %+v; want %+v", feed.Deleted, want)
+	}
+}
+
+func largeFeed(n int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<title>Example</title>
+	<link href="http://example.com/"/>
+`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `	<entry>
+		<id>urn:example:%d</id>
+		<title>entry %d</title>
+		<link href="http://example.com/%d"/>
+		<updated>2015-01-02T03:04:05Z</updated>
+		<dc:subject>news</dc:subject>
+		<content type="html">%s</content>
+	</entry>
+`, i, i, i, strings.Repeat("&lt;p&gt;lorem ipsum&lt;/p&gt;", 20))
+	}
+	b.WriteString("</feed>\n")
+	return b.String()
+}
+
+// TestParseAllocs guards allocations that BenchmarkParse and
+// BenchmarkDetectDialect measure against regressions.
+func TestParseAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	small, large := largeFeed(10), largeFeed(1000)
+	perEntry := (allocs(t, Parse, large) - allocs(t, Parse, small)) / 990
+	if perEntry > 185 {
+		t.Errorf("Parse allocates %.0f times per entry; want <= 185", perEntry)
+	}
+
+	// DetectDialect reads only the beginning of the document.
+	detect := func(r io.Reader) (*Feed, error) {
+		_, err := DetectDialect(r)
+		return nil, err
+	}
+	if n, m := allocs(t, detect, small), allocs(t, detect, large); n != m || m > 75 {
+		t.Errorf("DetectDialect allocates %.0f times for a small feed and %.0f times for a large one; want the same <= 75", n, m)
+	}
+}
+
+func allocs(t *testing.T, parse func(r io.Reader) (*Feed, error), s string) float64 {
+	t.Helper()
+	var err error
+	n := testing.AllocsPerRun(3, func() {
+		_, err = parse(strings.NewReader(s))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func BenchmarkParse(b *testing.B) {
+	s := largeFeed(1000)
+	b.ResetTimer()
+	b.SetBytes(int64(len(s)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(strings.NewReader(s)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDetectDialect(b *testing.B) {
+	s := largeFeed(1000)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DetectDialect(strings.NewReader(s)); err != nil {
+			b.Fatal(err)
 		}
 	}
 }
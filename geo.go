@@ -0,0 +1,37 @@
+package news
+
+import (
+	"github.com/lufia/news/geo"
+)
+
+var geoNamespaces = []string{
+	geo.GeoRSSNamespace,
+	geo.BasicNamespace,
+}
+
+// importGeo merges locations decoded from GeoRSS and W3C Basic Geo in modules.
+func importGeo(modules map[string]interface{}) *geo.Location {
+	var loc *geo.Location
+	for _, ns := range geoNamespaces {
+		v, ok := modules[ns].(*geo.Location)
+		if !ok || v.IsZero() {
+			continue
+		}
+		if loc == nil {
+			loc = &geo.Location{}
+		}
+		loc.Merge(v)
+	}
+	return loc
+}
+
+// ArticlesIn returns articles that have any geometry in the box b.
+func (feed *Feed) ArticlesIn(b geo.Box) []*Article {
+	var a []*Article
+	for _, p := range feed.Articles {
+		if p.Geo != nil && p.Geo.Intersects(b) {
+			a = append(a, p)
+		}
+	}
+	return a
+}
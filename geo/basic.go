@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/extension"
+)
+
+// BasicNamespace is the namespace of W3C Basic Geo (WGS84 lat/long) Vocabulary.
+const BasicNamespace = "http://www.w3.org/2003/01/geo/wgs84_pos#"
+
+type basicPoint struct {
+	Lat  string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# lat"`
+	Long string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# long"`
+	Alt  string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# alt"`
+}
+
+type basic struct {
+	basicPoint
+	Points []basicPoint `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# Point"`
+}
+
+func init() {
+	extension.Register(BasicNamespace, decodeBasic)
+}
+
+// decodeBasic decodes W3C Basic Geo elements to *Location.
+func decodeBasic(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var x basic
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return nil, err
+	}
+	var loc Location
+	for _, p := range append([]basicPoint{x.basicPoint}, x.Points...) {
+		lat, ok1 := parseFloat(p.Lat)
+		long, ok2 := parseFloat(p.Long)
+		if !ok1 || !ok2 {
+			continue
+		}
+		loc.Points = append(loc.Points, Point{Lat: lat, Long: long})
+		if v, ok := parseFloat(p.Alt); ok && loc.Elevation == nil {
+			loc.Elevation = &v
+		}
+	}
+	return &loc, nil
+}
@@ -0,0 +1,142 @@
+// Package geo implements GeoRSS and W3C Basic Geo vocabularies.
+//
+// This package registers decoders for GeoRSS Simple (including GML in georss:where)
+// and W3C Basic Geo to the extension package.
+// Both decoders return *Location. Malformed coordinates are ignored.
+package geo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Point is a WGS84 coordinate in degrees.
+type Point struct {
+	Lat  float64
+	Long float64
+}
+
+// Line is a sequence of points.
+type Line []Point
+
+// Polygon is a closed sequence of points; the first point equals the last.
+type Polygon []Point
+
+// Box is a bounding box.
+type Box struct {
+	Lower Point // south-west corner
+	Upper Point // north-east corner
+}
+
+// Contains reports whether b contains p.
+func (b Box) Contains(p Point) bool {
+	return b.Lower.Lat <= p.Lat && p.Lat <= b.Upper.Lat &&
+		b.Lower.Long <= p.Long && p.Long <= b.Upper.Long
+}
+
+// Overlaps reports whether b and b1 share any point.
+func (b Box) Overlaps(b1 Box) bool {
+	return b.Lower.Lat <= b1.Upper.Lat && b1.Lower.Lat <= b.Upper.Lat &&
+		b.Lower.Long <= b1.Upper.Long && b1.Lower.Long <= b.Upper.Long
+}
+
+// boundingBox returns the smallest box that contains a.
+func boundingBox(a []Point) Box {
+	b := Box{Lower: a[0], Upper: a[0]}
+	for _, p := range a[1:] {
+		if p.Lat < b.Lower.Lat {
+			b.Lower.Lat = p.Lat
+		}
+		if p.Long < b.Lower.Long {
+			b.Lower.Long = p.Long
+		}
+		if p.Lat > b.Upper.Lat {
+			b.Upper.Lat = p.Lat
+		}
+		if p.Long > b.Upper.Long {
+			b.Upper.Long = p.Long
+		}
+	}
+	return b
+}
+
+// Location is a set of geometries attached to a feed or an item.
+type Location struct {
+	Points       []Point
+	Lines        []Line
+	Polygons     []Polygon
+	Boxes        []Box
+	Elevation    *float64 // meters
+	FeatureNames []string
+}
+
+// IsZero returns true if loc has no geometries.
+func (loc *Location) IsZero() bool {
+	return len(loc.Points) == 0 && len(loc.Lines) == 0 &&
+		len(loc.Polygons) == 0 && len(loc.Boxes) == 0
+}
+
+// Intersects reports whether any geometry of loc is in b.
+// Lines and polygons are approximated by their bounding boxes.
+func (loc *Location) Intersects(b Box) bool {
+	for _, p := range loc.Points {
+		if b.Contains(p) {
+			return true
+		}
+	}
+	for _, l := range loc.Lines {
+		if len(l) > 0 && b.Overlaps(boundingBox(l)) {
+			return true
+		}
+	}
+	for _, poly := range loc.Polygons {
+		if len(poly) > 0 && b.Overlaps(boundingBox(poly)) {
+			return true
+		}
+	}
+	for _, b1 := range loc.Boxes {
+		if b.Overlaps(b1) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge appends geometries of loc1 to loc.
+func (loc *Location) Merge(loc1 *Location) {
+	loc.Points = append(loc.Points, loc1.Points...)
+	loc.Lines = append(loc.Lines, loc1.Lines...)
+	loc.Polygons = append(loc.Polygons, loc1.Polygons...)
+	loc.Boxes = append(loc.Boxes, loc1.Boxes...)
+	if loc.Elevation == nil {
+		loc.Elevation = loc1.Elevation
+	}
+	loc.FeatureNames = append(loc.FeatureNames, loc1.FeatureNames...)
+}
+
+// parsePoints parses s that is a list of "lat long" pairs separated by spaces.
+// Commas are also accepted as separators.
+func parsePoints(s string) ([]Point, bool) {
+	f := strings.Fields(strings.Replace(s, ",", " ", -1))
+	if len(f) == 0 || len(f)%2 != 0 {
+		return nil, false
+	}
+	a := make([]Point, len(f)/2)
+	for i := range a {
+		lat, ok := parseFloat(f[2*i])
+		if !ok {
+			return nil, false
+		}
+		long, ok := parseFloat(f[2*i+1])
+		if !ok {
+			return nil, false
+		}
+		a[i] = Point{Lat: lat, Long: long}
+	}
+	return a, true
+}
+
+func parseFloat(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/lufia/news/extension"
+)
+
+func decode(t *testing.T, s, ns string) *Location {
+	t.Helper()
+	var v struct {
+		Extensions []extension.Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := extension.New(v.Extensions).Decode(ns)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", ns, err)
+	}
+	loc, ok := p.(*Location)
+	if !ok {
+		t.Fatalf("Decode(%q) = %#v; want *Location", ns, p)
+	}
+	return loc
+}
+
+func TestDecodeGeoRSS(t *testing.T) {
+	s := `<item xmlns:georss="http://www.georss.org/georss" xmlns:gml="http://www.opengis.net/gml">
+		<georss:point>45.256 -71.92</georss:point>
+		<georss:line>45.256 -110.45 46.46 -109.48 43.84 -109.86</georss:line>
+		<georss:polygon>45.256 -110.45 46.46 -109.48 43.84 -109.86 45.256 -110.45</georss:polygon>
+		<georss:box>42.943 -71.032 43.039 -69.856</georss:box>
+		<georss:elev>313</georss:elev>
+		<georss:featurename>Mount Washington</georss:featurename>
+		<georss:point>broken</georss:point>
+		<georss:where>
+			<gml:Point><gml:pos>35.68 139.76</gml:pos></gml:Point>
+			<gml:Envelope>
+				<gml:lowerCorner>42.943 -71.032</gml:lowerCorner>
+				<gml:upperCorner>43.039 -69.856</gml:upperCorner>
+			</gml:Envelope>
+		</georss:where>
+	</item>`
+	loc := decode(t, s, GeoRSSNamespace)
+	elev := 313.0
+	want := &Location{
+		Points: []Point{{45.256, -71.92}, {35.68, 139.76}},
+		Lines: []Line{
+			{{45.256, -110.45}, {46.46, -109.48}, {43.84, -109.86}},
+		},
+		Polygons: []Polygon{
+			{{45.256, -110.45}, {46.46, -109.48}, {43.84, -109.86}, {45.256, -110.45}},
+		},
+		Boxes: []Box{
+			{Lower: Point{42.943, -71.032}, Upper: Point{43.039, -69.856}},
+			{Lower: Point{42.943, -71.032}, Upper: Point{43.039, -69.856}},
+		},
+		Elevation:    &elev,
+		FeatureNames: []string{"Mount Washington"},
+	}
+	if !reflect.DeepEqual(loc, want) {
+		t.Errorf("Decode(%q) = %+v; want %+v", GeoRSSNamespace, loc, want)
+	}
+}
+
+func TestDecodeBasic(t *testing.T) {
+	s := `<item xmlns:geo="http://www.w3.org/2003/01/geo/wgs84_pos#">
+		<geo:lat>35.68</geo:lat>
+		<geo:long>139.76</geo:long>
+		<geo:Point><geo:lat>34.69</geo:lat><geo:long>135.50</geo:long><geo:alt>10</geo:alt></geo:Point>
+	</item>`
+	loc := decode(t, s, BasicNamespace)
+	alt := 10.0
+	want := &Location{
+		Points:    []Point{{35.68, 139.76}, {34.69, 135.50}},
+		Elevation: &alt,
+	}
+	if !reflect.DeepEqual(loc, want) {
+		t.Errorf("Decode(%q) = %+v; want %+v", BasicNamespace, loc, want)
+	}
+}
+
+func TestLocationIntersects(t *testing.T) {
+	tokyo := Box{Lower: Point{35.5, 139.5}, Upper: Point{35.9, 140.0}}
+	tab := []struct {
+		Location Location
+		Want     bool
+	}{
+		{Location: Location{Points: []Point{{35.68, 139.76}}}, Want: true},
+		{Location: Location{Points: []Point{{34.69, 135.50}}}, Want: false},
+		{Location: Location{Lines: []Line{{{35.0, 139.0}, {36.0, 141.0}}}}, Want: true},
+		{Location: Location{Boxes: []Box{{Lower: Point{35.8, 139.9}, Upper: Point{36.0, 141.0}}}}, Want: true},
+		{Location: Location{}, Want: false},
+	}
+	for _, v := range tab {
+		if ok := v.Location.Intersects(tokyo); ok != v.Want {
+			t.Errorf("(%+v).Intersects(%v) = %v; want %v", v.Location, tokyo, ok, v.Want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package geo
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/extension"
+)
+
+const (
+	// GeoRSSNamespace is the namespace of GeoRSS.
+	GeoRSSNamespace = "http://www.georss.org/georss"
+
+	// GMLNamespace is the namespace of GML used in georss:where.
+	GMLNamespace = "http://www.opengis.net/gml"
+)
+
+type georss struct {
+	Points       []string `xml:"http://www.georss.org/georss point"`
+	Lines        []string `xml:"http://www.georss.org/georss line"`
+	Polygons     []string `xml:"http://www.georss.org/georss polygon"`
+	Boxes        []string `xml:"http://www.georss.org/georss box"`
+	Elevations   []string `xml:"http://www.georss.org/georss elev"`
+	FeatureNames []string `xml:"http://www.georss.org/georss featurename"`
+	Where        []gml    `xml:"http://www.georss.org/georss where"`
+}
+
+type gml struct {
+	Points []struct {
+		Pos string `xml:"http://www.opengis.net/gml pos"`
+	} `xml:"http://www.opengis.net/gml Point"`
+	Lines []struct {
+		PosList string `xml:"http://www.opengis.net/gml posList"`
+	} `xml:"http://www.opengis.net/gml LineString"`
+	Polygons []struct {
+		PosList string `xml:"http://www.opengis.net/gml exterior>LinearRing>posList"`
+	} `xml:"http://www.opengis.net/gml Polygon"`
+	Envelopes []struct {
+		Lower string `xml:"http://www.opengis.net/gml lowerCorner"`
+		Upper string `xml:"http://www.opengis.net/gml upperCorner"`
+	} `xml:"http://www.opengis.net/gml Envelope"`
+}
+
+func init() {
+	extension.Register(GeoRSSNamespace, decodeGeoRSS)
+}
+
+// decodeGeoRSS decodes GeoRSS Simple and GML elements to *Location.
+func decodeGeoRSS(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var x georss
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return nil, err
+	}
+	var loc Location
+	for _, s := range x.Points {
+		if a, ok := parsePoints(s); ok && len(a) == 1 {
+			loc.Points = append(loc.Points, a[0])
+		}
+	}
+	for _, s := range x.Lines {
+		if a, ok := parsePoints(s); ok && len(a) >= 2 {
+			loc.Lines = append(loc.Lines, Line(a))
+		}
+	}
+	for _, s := range x.Polygons {
+		if a, ok := parsePoints(s); ok && len(a) >= 4 {
+			loc.Polygons = append(loc.Polygons, Polygon(a))
+		}
+	}
+	for _, s := range x.Boxes {
+		if a, ok := parsePoints(s); ok && len(a) == 2 {
+			loc.Boxes = append(loc.Boxes, Box{Lower: a[0], Upper: a[1]})
+		}
+	}
+	for _, s := range x.Elevations {
+		if v, ok := parseFloat(s); ok {
+			loc.Elevation = &v
+			break
+		}
+	}
+	loc.FeatureNames = x.FeatureNames
+	for _, w := range x.Where {
+		for _, p := range w.Points {
+			if a, ok := parsePoints(p.Pos); ok && len(a) == 1 {
+				loc.Points = append(loc.Points, a[0])
+			}
+		}
+		for _, l := range w.Lines {
+			if a, ok := parsePoints(l.PosList); ok && len(a) >= 2 {
+				loc.Lines = append(loc.Lines, Line(a))
+			}
+		}
+		for _, poly := range w.Polygons {
+			if a, ok := parsePoints(poly.PosList); ok && len(a) >= 4 {
+				loc.Polygons = append(loc.Polygons, Polygon(a))
+			}
+		}
+		for _, e := range w.Envelopes {
+			lower, ok1 := parsePoints(e.Lower)
+			upper, ok2 := parsePoints(e.Upper)
+			if ok1 && ok2 && len(lower) == 1 && len(upper) == 1 {
+				loc.Boxes = append(loc.Boxes, Box{Lower: lower[0], Upper: upper[0]})
+			}
+		}
+	}
+	return &loc, nil
+}
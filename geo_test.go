@@ -0,0 +1,37 @@
+package news
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lufia/news/geo"
+)
+
+func TestFeedArticlesIn(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0"?>
+		<rss version="2.0"
+			xmlns:georss="http://www.georss.org/georss"
+			xmlns:geo="http://www.w3.org/2003/01/geo/wgs84_pos#">
+			<channel>
+				<title>Example</title>
+				<item><link>http://example.com/tokyo</link><georss:point>35.68 139.76</georss:point></item>
+				<item><link>http://example.com/osaka</link><geo:lat>34.69</geo:lat><geo:long>135.50</geo:long></item>
+				<item><link>http://example.com/none</link></item>
+			</channel>
+		</rss>`)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if feed.Articles[1].Geo == nil || len(feed.Articles[1].Geo.Points) != 1 {
+		t.Errorf("Articles[1].Geo = %+v", feed.Articles[1].Geo)
+	}
+	if feed.Articles[2].Geo != nil {
+		t.Errorf("Articles[2].Geo = %+v; want nil", feed.Articles[2].Geo)
+	}
+	box := geo.Box{Lower: geo.Point{Lat: 35.5, Long: 139.5}, Upper: geo.Point{Lat: 35.9, Long: 140.0}}
+	a := feed.ArticlesIn(box)
+	if len(a) != 1 || a[0].URL != "http://example.com/tokyo" {
+		t.Errorf("ArticlesIn(%v) = %v", box, a)
+	}
+}
@@ -0,0 +1,462 @@
+package news
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RepairKind is a kind of repairs that ParseLenient made.
+type RepairKind int
+
+const (
+	// RepairEntity is that a HTML entity was replaced with a character reference.
+	RepairEntity RepairKind = iota
+
+	// RepairAmpersand is that a bare ampersand was escaped.
+	RepairAmpersand
+
+	// RepairLessThan is that a bare '<' in text was escaped.
+	RepairLessThan
+
+	// RepairAttribute is that an unquoted attribute value was quoted.
+	RepairAttribute
+
+	// RepairUnclosedTag is that an unclosed element was closed automatically.
+	RepairUnclosedTag
+
+	// RepairUnexpectedEnd is that an end tag without the start tag was dropped.
+	RepairUnexpectedEnd
+)
+
+var repairKindNames = []string{
+	RepairEntity:        "entity",
+	RepairAmpersand:     "ampersand",
+	RepairLessThan:      "less-than",
+	RepairAttribute:     "attribute",
+	RepairUnclosedTag:   "unclosed tag",
+	RepairUnexpectedEnd: "unexpected end tag",
+}
+
+func (k RepairKind) String() string {
+	if k < 0 || int(k) >= len(repairKindNames) {
+		return fmt.Sprintf("RepairKind(%d)", int(k))
+	}
+	return repairKindNames[k]
+}
+
+// Repair is a record of a repair that ParseLenient made.
+type Repair struct {
+	Kind RepairKind
+
+	// Offset is the approximate byte offset in the input where the repair was made.
+	Offset int64
+
+	// Text is the original text, or the element name for tag repairs.
+	Text string
+}
+
+func (r Repair) String() string {
+	return fmt.Sprintf("%d: %v: %q", r.Offset, r.Kind, r.Text)
+}
+
+// ParseLenient parses r like Parse, but it recovers from common errors in
+// real feeds: HTML entities such as &nbsp;, bare ampersands and '<',
+// unquoted attribute values and unclosed or stray tags.
+// It returns the repairs that were made to parse r.
+func ParseLenient(r io.Reader) (feed *Feed, repairs []Repair, err error) {
+	opts := &ParseOptions{Lenient: true}
+	return opts.parse(r)
+}
+
+// newLenientTokenReader returns a token reader that reads tokens from r
+// with closing unclosed elements. If repairs is not nil,
+// newLenientTokenReader appends repairs to it.
+func newLenientTokenReader(r io.Reader, repairs *[]Repair) *lenientTokenReader {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = charsetReader
+	return &lenientTokenReader{d: d, repairs: repairs}
+}
+
+// lenientTokenReader is a xml.TokenReader that balances start and end elements.
+type lenientTokenReader struct {
+	d       *xml.Decoder
+	stack   []xml.Name
+	pending []xml.Token // tokens to return as is
+	peeked  xml.Token   // raw token read ahead
+	repairs *[]Repair
+}
+
+func (r *lenientTokenReader) record(kind RepairKind, name xml.Name) {
+	if r.repairs == nil {
+		return
+	}
+	s := name.Local
+	if name.Space != "" {
+		s = name.Space + ":" + name.Local
+	}
+	*r.repairs = append(*r.repairs, Repair{
+		Kind:   kind,
+		Offset: r.d.InputOffset(),
+		Text:   s,
+	})
+}
+
+func (r *lenientTokenReader) rawToken() (xml.Token, error) {
+	if r.peeked != nil {
+		tok := r.peeked
+		r.peeked = nil
+		return tok, nil
+	}
+	tok, err := r.d.RawToken()
+	if err != nil {
+		return nil, err
+	}
+	return xml.CopyToken(tok), nil
+}
+
+func (r *lenientTokenReader) Token() (xml.Token, error) {
+	for {
+		if len(r.pending) > 0 {
+			tok := r.pending[0]
+			r.pending = r.pending[1:]
+			return tok, nil
+		}
+		tok, err := r.rawToken()
+		if err == io.EOF && len(r.stack) > 0 {
+			name := r.pop()
+			r.record(RepairUnclosedTag, name)
+			return xml.EndElement{Name: name}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !isVoidElement(t.Name) {
+				r.stack = append(r.stack, t.Name)
+				return t, nil
+			}
+			// void elements like <br> never have children.
+			tok1, err := r.rawToken()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if end, ok := tok1.(xml.EndElement); !ok || end.Name != t.Name {
+				r.record(RepairUnclosedTag, t.Name)
+				r.peeked = tok1
+			}
+			r.pending = append(r.pending, xml.EndElement{Name: t.Name})
+			return t, nil
+		case xml.EndElement:
+			i := r.lookup(t.Name)
+			if i < 0 {
+				r.record(RepairUnexpectedEnd, t.Name)
+				continue
+			}
+			for len(r.stack)-1 > i {
+				name := r.pop()
+				r.record(RepairUnclosedTag, name)
+				r.pending = append(r.pending, xml.EndElement{Name: name})
+			}
+			r.pop()
+			r.pending = append(r.pending, t)
+		default:
+			return tok, nil
+		}
+	}
+}
+
+func (r *lenientTokenReader) pop() xml.Name {
+	name := r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+	return name
+}
+
+func (r *lenientTokenReader) lookup(name xml.Name) int {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if r.stack[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isVoidElement reports whether name is a HTML element that has no end tag.
+// The link element is excluded because it is common in feeds.
+func isVoidElement(name xml.Name) bool {
+	if name.Space != "" || name.Local == "link" {
+		return false
+	}
+	for _, s := range xml.HTMLAutoClose {
+		if strings.EqualFold(s, name.Local) {
+			return true
+		}
+	}
+	return false
+}
+
+// repairReader is an io.Reader that rewrites broken entities, bare ampersands,
+// bare '<' and unquoted attribute values to well-formed XML.
+type repairReader struct {
+	r       *bufio.Reader
+	buf     bytes.Buffer
+	offset  int64
+	state   int
+	special string // terminator of the special section
+	err     error
+	repairs *[]Repair
+}
+
+const (
+	stateText = iota
+	stateTag
+	stateSpecial // comments, CDATA sections, processing instructions and doctype
+)
+
+// maxEntityLen is the maximum length of a entity name to look ahead.
+const maxEntityLen = 32
+
+func newRepairReader(r io.Reader, repairs *[]Repair) *repairReader {
+	return &repairReader{r: bufio.NewReader(r), repairs: repairs}
+}
+
+func (r *repairReader) record(kind RepairKind, offset int64, s string) {
+	if r.repairs == nil {
+		return
+	}
+	*r.repairs = append(*r.repairs, Repair{Kind: kind, Offset: offset, Text: s})
+}
+
+func (r *repairReader) Read(p []byte) (int, error) {
+	for r.err == nil && r.buf.Len() < len(p) {
+		r.err = r.step()
+	}
+	if r.buf.Len() == 0 {
+		return 0, r.err
+	}
+	return r.buf.Read(p)
+}
+
+func (r *repairReader) readByte() (byte, error) {
+	c, err := r.r.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return c, err
+}
+
+func (r *repairReader) discard(n int) {
+	n, _ = r.r.Discard(n)
+	r.offset += int64(n)
+}
+
+// step reads a token-ish unit and writes repaired bytes into r.buf.
+func (r *repairReader) step() error {
+	switch r.state {
+	case stateSpecial:
+		return r.copySpecial()
+	}
+	c, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case c == '&':
+		r.repairReference()
+	case c == '<' && r.state == stateText:
+		r.startTag()
+	case c == '>' && r.state == stateTag:
+		r.buf.WriteByte(c)
+		r.state = stateText
+	case c == '=' && r.state == stateTag:
+		r.buf.WriteByte(c)
+		return r.attrValue()
+	case (c == '"' || c == '\'') && r.state == stateTag:
+		r.buf.WriteByte(c)
+		return r.quotedValue(c)
+	default:
+		r.buf.WriteByte(c)
+	}
+	return nil
+}
+
+var specialTerminators = []struct {
+	prefix string
+	end    string
+}{
+	{"!--", "-->"},
+	{"![CDATA[", "]]>"},
+	{"?", "?>"},
+	{"!", ">"},
+}
+
+func (r *repairReader) startTag() {
+	off := r.offset - 1
+	p, _ := r.r.Peek(len("![CDATA["))
+	for _, v := range specialTerminators {
+		if bytes.HasPrefix(p, []byte(v.prefix)) {
+			r.buf.WriteByte('<')
+			r.buf.WriteString(v.prefix)
+			r.discard(len(v.prefix))
+			r.state = stateSpecial
+			r.special = v.end
+			return
+		}
+	}
+	if len(p) > 0 && (p[0] == '/' || isNameStart(p[0])) {
+		r.buf.WriteByte('<')
+		r.state = stateTag
+		return
+	}
+	r.buf.WriteString("&lt;")
+	r.record(RepairLessThan, off, "<")
+}
+
+func (r *repairReader) copySpecial() error {
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		r.buf.WriteByte(c)
+		if c == r.special[len(r.special)-1] && bytes.HasSuffix(r.buf.Bytes(), []byte(r.special)) {
+			r.state = stateText
+			return nil
+		}
+	}
+}
+
+func (r *repairReader) quotedValue(quote byte) error {
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '&':
+			r.repairReference()
+		case '<':
+			r.buf.WriteString("&lt;")
+		default:
+			r.buf.WriteByte(c)
+		}
+		if c == quote {
+			return nil
+		}
+	}
+}
+
+func (r *repairReader) attrValue() error {
+	for {
+		p, err := r.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch c := p[0]; c {
+		case ' ', '\t', '\r', '\n':
+			r.buf.WriteByte(c)
+			r.discard(1)
+			continue
+		case '"', '\'':
+			r.discard(1)
+			r.buf.WriteByte(c)
+			return r.quotedValue(c)
+		case '>':
+			return nil
+		}
+		break
+	}
+
+	off := r.offset
+	var value bytes.Buffer
+	for {
+		p, err := r.r.Peek(1)
+		if err != nil || isSpace(p[0]) || p[0] == '>' {
+			break
+		}
+		c, _ := r.readByte()
+		value.WriteByte(c)
+	}
+	r.buf.WriteByte('"')
+	for _, c := range value.Bytes() {
+		switch c {
+		case '"':
+			r.buf.WriteString("&quot;")
+		case '<':
+			r.buf.WriteString("&lt;")
+		case '&':
+			r.buf.WriteString("&amp;")
+		default:
+			r.buf.WriteByte(c)
+		}
+	}
+	r.buf.WriteByte('"')
+	r.record(RepairAttribute, off, value.String())
+	return nil
+}
+
+// repairReference is called after '&' was read.
+func (r *repairReader) repairReference() {
+	off := r.offset - 1
+	p, _ := r.r.Peek(maxEntityLen + 1)
+	i := bytes.IndexByte(p, ';')
+	if i > 0 {
+		name := string(p[:i])
+		switch {
+		case isCharRef(name):
+			r.buf.WriteByte('&')
+			return
+		case name == "amp" || name == "lt" || name == "gt" || name == "quot" || name == "apos":
+			r.buf.WriteByte('&')
+			return
+		case xml.HTMLEntity[name] != "":
+			for _, c := range xml.HTMLEntity[name] {
+				fmt.Fprintf(&r.buf, "&#%d;", c)
+			}
+			r.discard(i + 1)
+			r.record(RepairEntity, off, "&"+name+";")
+			return
+		}
+	}
+	r.buf.WriteString("&amp;")
+	r.record(RepairAmpersand, off, "&")
+}
+
+func isCharRef(s string) bool {
+	if len(s) < 2 || s[0] != '#' {
+		return false
+	}
+	s = s[1:]
+	hex := false
+	if s[0] == 'x' {
+		hex = true
+		s = s[1:]
+	}
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case '0' <= c && c <= '9':
+		case hex && ('a' <= c && c <= 'f' || 'A' <= c && c <= 'F'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == ':' || c >= 0x80
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
@@ -0,0 +1,132 @@
+package news
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lufia/news/dublincore"
+)
+
+func TestParseLenient(t *testing.T) {
+	s := `<?xml version="1.0"?>
+<rss version="2.0">
+	<channel>
+		<title>Tom&nbsp;&amp;&nbsp;Jerry &copy; 2015 &hellip;</title>
+		<link>http://example.com/?a=1&b=2</link>
+		<description><![CDATA[a & b]]></description>
+		<item>
+			<title>1 < 2 &#x3042; &unknown;</title>
+			<link>http://example.com/1</link>
+			<enclosure url=http://example.com/a.mp3 length="1" type="audio/mpeg"/>
+			<description>line<br>break</description>
+			<category>news
+		</item>
+		</foo>
+	</channel>
+</rss>`
+	feed, repairs, err := ParseLenient(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("ParseLenient: %v", err)
+	}
+	if want := "Tom & Jerry © 2015 …"; feed.Title != want {
+		t.Errorf("Title = %q; want %q", feed.Title, want)
+	}
+	if want := "http://example.com/?a=1&b=2"; feed.URL != want {
+		t.Errorf("URL = %q; want %q", feed.URL, want)
+	}
+	if want := "a & b"; feed.Summary != want {
+		t.Errorf("Summary = %q; want %q", feed.Summary, want)
+	}
+	if len(feed.Articles) != 1 {
+		t.Fatalf("len(Articles) = %d; want 1", len(feed.Articles))
+	}
+	p := feed.Articles[0]
+	if want := "1 < 2 あ &unknown;"; p.Title != want {
+		t.Errorf("Title = %q; want %q", p.Title, want)
+	}
+	if want := "linebreak"; p.Content != want {
+		t.Errorf("Content = %q; want %q", p.Content, want)
+	}
+
+	count := make(map[RepairKind]int)
+	for _, r := range repairs {
+		count[r.Kind]++
+	}
+	want := map[RepairKind]int{
+		RepairEntity:        4,
+		RepairAmpersand:     2,
+		RepairLessThan:      1,
+		RepairAttribute:     1,
+		RepairUnclosedTag:   2,
+		RepairUnexpectedEnd: 1,
+	}
+	for kind, n := range want {
+		if count[kind] != n {
+			t.Errorf("%v repairs = %d; want %d: %v", kind, count[kind], n, repairs)
+		}
+	}
+}
+
+func TestParseLenientValid(t *testing.T) {
+	s := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title type="html">a &lt;b&gt; &amp; c</title>
+	<entry><id>urn:example:1</id><title>entry</title></entry>
+</feed>`
+	feed, repairs, err := ParseLenient(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("ParseLenient: %v", err)
+	}
+	if len(repairs) != 0 {
+		t.Errorf("repairs = %v; want none", repairs)
+	}
+	if want := "a <b> & c"; feed.Title != want {
+		t.Errorf("Title = %q; want %q", feed.Title, want)
+	}
+	if len(feed.Articles) != 1 || feed.Articles[0].ID != "urn:example:1" {
+		t.Errorf("Articles = %v", feed.Articles)
+	}
+}
+
+func TestParseLenientDates(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	opts := &ParseOptions{Location: loc, TimeZone: loc, Lenient: true}
+	s := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>a &nbsp;</title>
+	<entry><id>urn:example:1</id><title>entry</title><updated>2024-01-02T03:04:05</updated></entry>
+</feed>`
+	feed, err := ParseWithOptions(strings.NewReader(s), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions(Atom) = %v", err)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, loc)
+	if p := feed.Articles[0]; !p.Updated.Equal(want) {
+		t.Errorf("Atom: Updated = %v; want %v", p.Updated, want)
+	}
+
+	s = `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="http://example.com/"><title>a &nbsp;</title></channel>
+	<item rdf:about="http://example.com/1">
+		<title>1</title>
+		<link>http://example.com/1</link>
+		<dc:creator>Alice</dc:creator>
+		<dc:date>2024-01-02T03:04:05</dc:date>
+	</item>
+</rdf:RDF>`
+	feed, err = ParseWithOptions(strings.NewReader(s), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions(RSS 1.0) = %v", err)
+	}
+	p := feed.Articles[0]
+	if !p.Published.Equal(want) {
+		t.Errorf("RSS 1.0: Published = %v; want %v", p.Published, want)
+	}
+	if !reflect.DeepEqual(p.Authors, []string{"Alice"}) {
+		t.Errorf("RSS 1.0: Authors = %q; want [Alice]", p.Authors)
+	}
+	if err := p.ExtensionError(dublincore.Namespace); err != nil {
+		t.Errorf("RSS 1.0: ExtensionError = %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package news
+
+import (
+	"io"
+
+	"github.com/lufia/news/limit"
+)
+
+// Limits restricts resources that parsing a feed may consume.
+// Zero value of each field means no limit.
+type Limits = limit.Limits
+
+// DefaultLimits is the limits that Parse and ParseLenient use.
+var DefaultLimits = limit.Default
+
+// LimitError is the error that is returned when a document exceeds Limits.
+type LimitError = limit.Error
+
+// ParseWithLimits parses r like Parse, but with the limits instead of DefaultLimits.
+func ParseWithLimits(r io.Reader, limits Limits) (*Feed, error) {
+	return ParseWithOptions(r, &ParseOptions{Limits: &limits})
+}
+
+// checkContentLength checks contents of articles in feed against limits.MaxContentLength.
+func checkContentLength(limits *Limits, feed *Feed) error {
+	if limits.MaxContentLength <= 0 {
+		return nil
+	}
+	for _, p := range feed.Articles {
+		if len(p.Content) > limits.MaxContentLength {
+			return &LimitError{Name: "MaxContentLength", Limit: int64(limits.MaxContentLength)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package limit restricts resources that decoding feed documents consumes.
+package limit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Limits restricts resources that parsing a feed may consume.
+// Zero value of each field means no limit.
+type Limits struct {
+	MaxBytes         int64 // size of the document
+	MaxDepth         int   // nesting depth of elements
+	MaxAttrs         int   // number of attributes per element
+	MaxTextLength    int   // length of each text node
+	MaxItems         int   // number of items or entries
+	MaxContentLength int   // length of the content of each article
+}
+
+// Default is the limits that Parse of dialect packages use.
+var Default = Limits{
+	MaxBytes:         64 << 20,
+	MaxDepth:         256,
+	MaxAttrs:         256,
+	MaxTextLength:    16 << 20,
+	MaxItems:         100000,
+	MaxContentLength: 16 << 20,
+}
+
+// Error is the error that is returned when a document exceeds Limits.
+type Error struct {
+	Name  string // name of the field in Limits
+	Limit int64
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("news: document exceeds %s (%d)", e.Name, e.Limit)
+}
+
+// NewDecoder returns a decoder that reads a document from r within limits.
+// MaxContentLength is not checked because it is about converted articles.
+func (limits *Limits) NewDecoder(r io.Reader) *xml.Decoder {
+	return xml.NewTokenDecoder(limits.TokenReader(xml.NewDecoder(limits.Reader(r))))
+}
+
+// Reader returns a reader that reads from r
+// and fails with Error after limits.MaxBytes bytes.
+func (limits *Limits) Reader(r io.Reader) io.Reader {
+	if limits.MaxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: limits.MaxBytes, limit: limits.MaxBytes}
+}
+
+type limitedReader struct {
+	r     io.Reader
+	n     int64 // bytes remaining
+	limit int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.n < 0 {
+		return 0, &Error{Name: "MaxBytes", Limit: r.limit}
+	}
+	// read one more byte than the limit to detect excess.
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+	if r.n < 0 {
+		return 0, &Error{Name: "MaxBytes", Limit: r.limit}
+	}
+	return n, err
+}
+
+// TokenReader returns a token reader that reads tokens from r
+// and fails with Error if the tokens exceed limits.
+func (limits *Limits) TokenReader(r xml.TokenReader) xml.TokenReader {
+	return &tokenReader{r: r, limits: limits}
+}
+
+type tokenReader struct {
+	r      xml.TokenReader
+	limits *Limits
+	depth  int
+	items  int
+}
+
+func (r *tokenReader) Token() (xml.Token, error) {
+	tok, err := r.r.Token()
+	if err != nil {
+		return nil, err
+	}
+	limits := r.limits
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.depth++
+		if limits.MaxDepth > 0 && r.depth > limits.MaxDepth {
+			return nil, &Error{Name: "MaxDepth", Limit: int64(limits.MaxDepth)}
+		}
+		if limits.MaxAttrs > 0 && len(t.Attr) > limits.MaxAttrs {
+			return nil, &Error{Name: "MaxAttrs", Limit: int64(limits.MaxAttrs)}
+		}
+		if t.Name.Local == "item" || t.Name.Local == "entry" {
+			r.items++
+			if limits.MaxItems > 0 && r.items > limits.MaxItems {
+				return nil, &Error{Name: "MaxItems", Limit: int64(limits.MaxItems)}
+			}
+		}
+	case xml.EndElement:
+		r.depth--
+	case xml.CharData:
+		if limits.MaxTextLength > 0 && len(t) > limits.MaxTextLength {
+			return nil, &Error{Name: "MaxTextLength", Limit: int64(limits.MaxTextLength)}
+		}
+	}
+	return tok, nil
+}
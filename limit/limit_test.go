@@ -0,0 +1,52 @@
+package limit
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewDecoder(t *testing.T) {
+	items := `<rss><channel>` + strings.Repeat(`<item><title>0123456789</title></item>`, 3) + `</channel></rss>`
+	tab := []struct {
+		Name   string
+		Limits Limits
+		Doc    string
+	}{
+		{"MaxBytes", Limits{MaxBytes: 50}, items},
+		{"MaxItems", Limits{MaxItems: 2}, items},
+		{"MaxTextLength", Limits{MaxTextLength: 5}, items},
+		{"MaxDepth", Limits{MaxDepth: 3}, items},
+		{"MaxAttrs", Limits{MaxAttrs: 1}, `<rss a="1" b="2"/>`},
+	}
+	for _, v := range tab {
+		err := readAll(v.Limits, v.Doc)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: Token = %v; want Error", v.Name, err)
+			continue
+		}
+		if e.Name != v.Name {
+			t.Errorf("%s: Error.Name = %q", v.Name, e.Name)
+		}
+	}
+	if err := readAll(Limits{MaxItems: 3, MaxDepth: 4, MaxBytes: int64(len(items))}, items); err != nil {
+		t.Errorf("Token = %v; want nil", err)
+	}
+	if err := readAll(Limits{}, items); err != nil {
+		t.Errorf("Token with no limits = %v; want nil", err)
+	}
+}
+
+func readAll(limits Limits, s string) error {
+	d := limits.NewDecoder(strings.NewReader(s))
+	for {
+		if _, err := d.Token(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package news
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseWithLimits(t *testing.T) {
+	items := `<rss version="2.0"><channel><title>t</title>` +
+		strings.Repeat(`<item><title>a</title><description>0123456789</description></item>`, 3) +
+		`</channel></rss>`
+	tab := []struct {
+		Name   string
+		Limits Limits
+		Doc    string
+	}{
+		{"MaxBytes", Limits{MaxBytes: 100}, items},
+		{"MaxItems", Limits{MaxItems: 2}, items},
+		{"MaxContentLength", Limits{MaxContentLength: 5}, items},
+		{"MaxTextLength", Limits{MaxTextLength: 5}, items},
+		{"MaxDepth", Limits{MaxDepth: 10}, `<rss version="2.0"><channel>` + strings.Repeat("<a>", 9) + strings.Repeat("</a>", 9) + `</channel></rss>`},
+		{"MaxAttrs", Limits{MaxAttrs: 2}, `<rss version="2.0" a="1" b="2"></rss>`},
+	}
+	for _, v := range tab {
+		_, err := ParseWithLimits(strings.NewReader(v.Doc), v.Limits)
+		var e *LimitError
+		if !errors.As(err, &e) {
+			t.Errorf("%s: ParseWithLimits = %v; want LimitError", v.Name, err)
+			continue
+		}
+		if e.Name != v.Name {
+			t.Errorf("%s: LimitError.Name = %q", v.Name, e.Name)
+		}
+	}
+
+	feed, err := ParseWithLimits(strings.NewReader(items), Limits{MaxItems: 3, MaxBytes: int64(len(items))})
+	if err != nil {
+		t.Fatalf("ParseWithLimits = %v", err)
+	}
+	if len(feed.Articles) != 3 {
+		t.Errorf("len(Articles) = %d; want 3", len(feed.Articles))
+	}
+}
+
+func TestDetectDialectLimits(t *testing.T) {
+	s := "<rss" + strings.Repeat(` a="1"`, DefaultLimits.MaxAttrs+1) + ">"
+	_, err := DetectDialect(strings.NewReader(s))
+	var e *LimitError
+	if !errors.As(err, &e) || e.Name != "MaxAttrs" {
+		t.Errorf("DetectDialect = %v; want MaxAttrs error", err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(`<rss version="2.0"><channel><item><title>a</title></item></channel></rss>`)
+	f.Add(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id></entry></feed>`)
+	f.Add(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel/></rdf:RDF>`)
+	f.Add(`<rss version="2.0"/>`)
+	f.Add(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"/>`)
+	limits := Limits{MaxBytes: 1 << 16, MaxDepth: 32, MaxItems: 64}
+	f.Fuzz(func(t *testing.T, s string) {
+		ParseWithLimits(strings.NewReader(s), limits)
+		ParseLenient(strings.NewReader(s))
+	})
+}
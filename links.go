@@ -0,0 +1,138 @@
+package news
+
+import (
+	"strconv"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/rss2"
+)
+
+// Link is a link from a feed or an article to a resource.
+type Link struct {
+	URL      string
+	Rel      string // "alternate" if the document omits it
+	Type     string // advisory media type
+	HrefLang string
+	Title    string
+	Length   int64 // advisory length in bytes; zero if unknown
+}
+
+// Link returns the first link of the feed which relation is rel.
+// It returns nil if there is no such link.
+func (feed *Feed) Link(rel string) *Link {
+	return findLink(feed.Links, rel)
+}
+
+// Link returns the first link of the article which relation is rel.
+// It returns nil if there is no such link.
+func (p *Article) Link(rel string) *Link {
+	return findLink(p.Links, rel)
+}
+
+// SelfURL returns the canonical URL of the feed that is linked with rel="self".
+// If it differs from the URL that the feed was fetched from, the feed might have moved.
+func (feed *Feed) SelfURL() string {
+	if link := feed.Link("self"); link != nil {
+		return link.URL
+	}
+	return ""
+}
+
+// Hubs returns URLs of WebSub hubs that are linked with rel="hub".
+// The publisher of the feed notifies the hubs of its updates in real time.
+func (feed *Feed) Hubs() []string {
+	var a []string
+	for _, link := range findLinks(feed.Links, "hub") {
+		a = append(a, link.URL)
+	}
+	return a
+}
+
+func findLink(links []*Link, rel string) *Link {
+	if a := findLinks(links, rel); len(a) > 0 {
+		return a[0]
+	}
+	return nil
+}
+
+// findLinks returns links which relation is rel.
+func findLinks(links []*Link, rel string) []*Link {
+	var a []*Link
+	for _, link := range links {
+		if link.Rel == rel {
+			a = append(a, link)
+		}
+	}
+	return a
+}
+
+// alternateLink returns a link to url in the relation of alternate.
+// It returns nil if url is empty.
+func alternateLink(url string) []*Link {
+	if url == "" {
+		return nil
+	}
+	return []*Link{{URL: url, Rel: "alternate"}}
+}
+
+func importAtomLinks(links []atom.Link) []*Link {
+	a := make([]*Link, 0, len(links))
+	for _, link := range links {
+		a = append(a, &Link{
+			URL:      link.URL,
+			Rel:      linkRel(link.Rel),
+			Type:     link.Type,
+			HrefLang: link.HrefLang,
+			Title:    link.Title,
+			Length:   link.Length,
+		})
+	}
+	return a
+}
+
+// importExtensionLinks returns atom:link elements embedded in other dialects.
+func importExtensionLinks(x extension.Extensions) []*Link {
+	var a []*Link
+	for _, ns := range []string{atom.Namespace, atom.Namespace03} {
+		for _, e := range x.Get(ns, "link") {
+			n, _ := strconv.ParseInt(e.Attr("length"), 10, 64)
+			a = append(a, &Link{
+				URL:      e.Attr("href"),
+				Rel:      linkRel(e.Attr("rel")),
+				Type:     e.Attr("type"),
+				HrefLang: e.Attr("hreflang"),
+				Title:    e.Attr("title"),
+				Length:   n,
+			})
+		}
+	}
+	return a
+}
+
+func importRSS2ItemLinks(item *rss2.Item) []*Link {
+	a := alternateLink(item.Link)
+	if e := item.Enclosure; e != nil && e.URL != "" {
+		a = append(a, &Link{
+			URL:    e.URL,
+			Rel:    "enclosure",
+			Type:   e.Type,
+			Length: e.Length,
+		})
+	}
+	if s := item.Source; s != nil && s.URL != "" {
+		a = append(a, &Link{
+			URL:   s.URL,
+			Rel:   "via",
+			Title: s.Title,
+		})
+	}
+	return a
+}
+
+func linkRel(rel string) string {
+	if rel == "" {
+		return "alternate"
+	}
+	return rel
+}
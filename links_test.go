@@ -0,0 +1,143 @@
+package news
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAtomLinks(t *testing.T) {
+	s := `<feed xmlns="http://www.w3.org/2005/Atom">
+		<link rel="self" type="application/atom+xml" href="http://example.com/feed.atom"/>
+		<link href="http://example.com/"/>
+		<entry>
+			<id>urn:example:1</id>
+			<link href="http://example.com/1"/>
+			<link rel="alternate" hreflang="ja" href="http://example.com/ja/1"/>
+			<link rel="related" title="Related" href="http://example.com/2"/>
+			<link rel="enclosure" type="audio/mpeg" length="1337" href="http://example.com/1.mp3"/>
+		</entry>
+	</feed>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	want := []*Link{
+		{URL: "http://example.com/feed.atom", Rel: "self", Type: "application/atom+xml"},
+		{URL: "http://example.com/", Rel: "alternate"},
+	}
+	if !reflect.DeepEqual(feed.Links, want) {
+		t.Errorf("Links = %v; want %v", feed.Links, want)
+	}
+	p := feed.Articles[0]
+	want = []*Link{
+		{URL: "http://example.com/1", Rel: "alternate"},
+		{URL: "http://example.com/ja/1", Rel: "alternate", HrefLang: "ja"},
+		{URL: "http://example.com/2", Rel: "related", Title: "Related"},
+		{URL: "http://example.com/1.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: 1337},
+	}
+	if !reflect.DeepEqual(p.Links, want) {
+		t.Errorf("Articles[0].Links = %v; want %v", p.Links, want)
+	}
+	if link := p.Link("related"); link == nil || link.URL != "http://example.com/2" {
+		t.Errorf("Link(related) = %v", link)
+	}
+	if link := p.Link("edit"); link != nil {
+		t.Errorf("Link(edit) = %v; want nil", link)
+	}
+}
+
+func TestParseRSS2Links(t *testing.T) {
+	s := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+		<channel>
+			<title>a</title>
+			<link>http://example.com/</link>
+			<atom:link rel="self" type="application/rss+xml" href="http://example.com/feed.rss"/>
+			<atom:link rel="hub" href="http://hub.example.com/"/>
+			<item>
+				<title>1</title>
+				<link>http://example.com/1</link>
+				<enclosure url="http://example.com/1.mp3" length="1337" type="audio/mpeg"/>
+				<source url="http://example.org/rss">Example</source>
+				<atom:link rel="related" href="http://example.com/2"/>
+			</item>
+		</channel>
+	</rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	want := []*Link{
+		{URL: "http://example.com/", Rel: "alternate"},
+		{URL: "http://example.com/feed.rss", Rel: "self", Type: "application/rss+xml"},
+		{URL: "http://hub.example.com/", Rel: "hub"},
+	}
+	if !reflect.DeepEqual(feed.Links, want) {
+		t.Errorf("Links = %v; want %v", feed.Links, want)
+	}
+	want = []*Link{
+		{URL: "http://example.com/1", Rel: "alternate"},
+		{URL: "http://example.com/1.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: 1337},
+		{URL: "http://example.org/rss", Rel: "via", Title: "Example"},
+		{URL: "http://example.com/2", Rel: "related"},
+	}
+	if p := feed.Articles[0]; !reflect.DeepEqual(p.Links, want) {
+		t.Errorf("Articles[0].Links = %v; want %v", p.Links, want)
+	}
+}
+
+func TestFeedSelfURLAndHubs(t *testing.T) {
+	tab := []struct {
+		Name      string
+		XMLString string
+	}{
+		{
+			Name: "rss1",
+			XMLString: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:atom="http://www.w3.org/2005/Atom">
+				<channel rdf:about="http://example.com/feed">
+					<title>a</title>
+					<link>http://example.com/</link>
+					<atom:link rel="self" href="/feed"/>
+					<atom:link rel="hub" href="http://hub.example.com/"/>
+				</channel>
+			</rdf:RDF>`,
+		},
+		{
+			Name: "rss2",
+			XMLString: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+				<channel>
+					<title>a</title>
+					<link>http://example.com/</link>
+					<atom:link rel="self" href="/feed"/>
+					<atom:link rel="hub" href="http://hub.example.com/"/>
+				</channel>
+			</rss>`,
+		},
+		{
+			Name: "atom",
+			XMLString: `<feed xmlns="http://www.w3.org/2005/Atom">
+				<link href="http://example.com/"/>
+				<link rel="self" href="/feed"/>
+				<link rel="hub" href="http://hub.example.com/"/>
+			</feed>`,
+		},
+	}
+	for _, v := range tab {
+		feed, err := ParseWithOptions(strings.NewReader(v.XMLString), &ParseOptions{
+			BaseURL: "http://example.com/",
+		})
+		if err != nil {
+			t.Errorf("%s: Parse = %v", v.Name, err)
+			continue
+		}
+		if s := feed.SelfURL(); s != "http://example.com/feed" {
+			t.Errorf("%s: SelfURL() = %q; want %q", v.Name, s, "http://example.com/feed")
+		}
+		if a := feed.Hubs(); !reflect.DeepEqual(a, []string{"http://hub.example.com/"}) {
+			t.Errorf("%s: Hubs() = %q", v.Name, a)
+		}
+		if feed.URL != "http://example.com/" {
+			t.Errorf("%s: URL = %q; want %q", v.Name, feed.URL, "http://example.com/")
+		}
+	}
+}
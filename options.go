@@ -0,0 +1,241 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"time"
+)
+
+// ParseOptions configures ParseWithOptions.
+// Zero value of each field means the default behaviour of Parse.
+type ParseOptions struct {
+	// Context cancels parsing; nil means context.Background().
+	Context context.Context
+
+	// BaseURL is used to resolve relative URLs in the feed.
+	BaseURL string
+
+	// Location is the time zone for dates that have no time zone;
+	// nil means UTC.
+	Location *time.Location
+
+	// TimeZone is the time zone that dates in the feed are normalized to;
+	// nil means UTC.
+	TimeZone *time.Location
+
+	// FetchTime is the time when the document was fetched;
+	// zero means the time when the document is parsed.
+	FetchTime time.Time
+
+	// FirstSeen returns the time when the article was seen first.
+	// It is used as the estimated published date of articles that have no
+	// valid dates. If FirstSeen is nil, FetchTime is used.
+	FirstSeen func(p *Article) time.Time
+
+	// Lenient makes the parser to recover from broken documents like ParseLenient.
+	Lenient bool
+
+	// Sanitize is the policy to sanitize HTML of summaries and contents.
+	Sanitize SanitizePolicy
+
+	// Limits restricts resources that parsing consumes; nil means DefaultLimits.
+	Limits *Limits
+}
+
+func (opts *ParseOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+func (opts *ParseOptions) location() *time.Location {
+	if opts.Location == nil {
+		return time.UTC
+	}
+	return opts.Location
+}
+
+func (opts *ParseOptions) timeZone() *time.Location {
+	if opts.TimeZone == nil {
+		return time.UTC
+	}
+	return opts.TimeZone
+}
+
+func (opts *ParseOptions) limits() *Limits {
+	if opts.Limits == nil {
+		return &DefaultLimits
+	}
+	return opts.Limits
+}
+
+// ParseWithOptions parses a feed of any dialect from r with opts.
+// If opts is nil, it is the same as Parse.
+func ParseWithOptions(r io.Reader, opts *ParseOptions) (*Feed, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	feed, _, err := opts.parse(r)
+	return feed, err
+}
+
+// NewDecoder returns a decoder that reads a document from r with opts;
+// it is for decoders of dialect packages such as rss2.Decode.
+// The decoder applies Context, Location, Lenient and Limits except
+// MaxContentLength to the document. The rest of opts are applied by
+// Import* functions such as Feed.ImportFromRSS2.
+//
+// NewDecoder returns an error such as ErrNotXML if the leading bytes of r
+// are obviously not a feed. If opts is nil, the defaults are used.
+func (opts *ParseOptions) NewDecoder(r io.Reader) (*xml.Decoder, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	d, _, err := opts.newDecoder(r, nil)
+	return d, err
+}
+
+// newDecoder returns a decoder of r with opts, and the tracker of positions
+// in r for reporting errors. If opts.Lenient is true and repairs is not nil,
+// repairs that were made are appended to repairs.
+func (opts *ParseOptions) newDecoder(r io.Reader, repairs *[]Repair) (*xml.Decoder, *pathTracker, error) {
+	limits := opts.limits()
+	r, err := bomReader(limits.Reader(&contextReader{ctx: opts.context(), r: r}))
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err = sniffReader(NewCleanReader(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	var t *pathTracker
+	if opts.Lenient {
+		l := newLenientTokenReader(newRepairReader(r, repairs), repairs)
+		// lenientTokenReader returns names with prefixes;
+		// dateTokenReader needs their namespaces.
+		t = newPathTracker(xml.NewTokenDecoder(l), l.d)
+	} else {
+		d := xml.NewDecoder(r)
+		d.CharsetReader = charsetReader
+		t = newPathTracker(d, d)
+	}
+	dates := &dateTokenReader{r: t, loc: opts.location(), lenient: opts.Lenient}
+	return xml.NewTokenDecoder(limits.TokenReader(dates)), t, nil
+}
+
+// parse parses r with opts. It returns repairs that were made if opts.Lenient is true.
+func (opts *ParseOptions) parse(r io.Reader) (feed *Feed, repairs []Repair, err error) {
+	if err = opts.context().Err(); err != nil {
+		return
+	}
+	d, t, err := opts.newDecoder(r, &repairs)
+	if err != nil {
+		return
+	}
+	feed, err = decode(d, t)
+	if err != nil {
+		return
+	}
+	if err = feed.applyOptions(opts); err != nil {
+		return nil, repairs, err
+	}
+	return
+}
+
+// applyOptions applies opts that are not about decoding documents to feed.
+// If opts is nil, the defaults are applied.
+func (feed *Feed) applyOptions(opts *ParseOptions) error {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	fetchTime := opts.FetchTime
+	if fetchTime.IsZero() {
+		fetchTime = time.Now()
+	}
+	feed.Fetched = fetchTime
+	feed.normalizeDates(opts.timeZone(), fetchTime, opts.FirstSeen)
+	if opts.BaseURL != "" {
+		if err := feed.resolveURLs(opts.BaseURL); err != nil {
+			return err
+		}
+	}
+	feed.sanitize(opts.Sanitize)
+	return checkContentLength(opts.limits(), feed)
+}
+
+// contextReader is an io.Reader that fails after ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// resolveURLs resolves relative URLs in feed against base.
+func (feed *Feed) resolveURLs(base string) error {
+	u, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+	resolve := func(s *string) {
+		if *s == "" {
+			return
+		}
+		if r, err := u.Parse(*s); err == nil {
+			*s = r.String()
+		}
+	}
+	resolve(&feed.URL)
+	for _, link := range feed.Links {
+		resolve(&link.URL)
+	}
+	if feed.Image != nil {
+		resolve(&feed.Image.URL)
+		resolve(&feed.Image.Link)
+	}
+	for _, s := range []*string{
+		&feed.Paging.First, &feed.Paging.Last,
+		&feed.Paging.Previous, &feed.Paging.Next, &feed.Paging.Current,
+		&feed.Paging.PrevArchive, &feed.Paging.NextArchive,
+	} {
+		resolve(s)
+	}
+	for _, p := range feed.Articles {
+		resolve(&p.URL)
+		resolve(&p.Permalink)
+		for _, link := range p.Links {
+			resolve(&link.URL)
+		}
+		if d := p.Discussion; d != nil {
+			resolve(&d.URL)
+			resolve(&d.FeedURL)
+			for _, r := range d.InReplyTo {
+				resolve(&r.URL)
+				resolve(&r.Source)
+			}
+		}
+	}
+	for _, p := range feed.Deleted {
+		resolve(&p.URL)
+	}
+	return nil
+}
+
+// sanitize sanitizes HTML of feed by policy.
+func (feed *Feed) sanitize(policy SanitizePolicy) {
+	if policy == SanitizeNone {
+		return
+	}
+	feed.Summary = policy.Sanitize(feed.Summary)
+	for _, p := range feed.Articles {
+		p.Content = policy.Sanitize(p.Content)
+	}
+}
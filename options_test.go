@@ -0,0 +1,198 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lufia/news/rss2"
+)
+
+func TestParseWithOptionsLocation(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	tab := []struct {
+		s    string
+		want time.Time
+	}{
+		{
+			s: `<rss version="2.0"><channel><item>
+				<title>a</title><pubDate>Fri, 2 Jan 2015 03:04:05</pubDate>
+			</item></channel></rss>`,
+			want: time.Date(2015, 1, 2, 3, 4, 5, 0, loc),
+		},
+		{
+			s: `<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+				<id>1</id><updated>2015-01-02T03:04:05</updated>
+			</entry></feed>`,
+			want: time.Date(2015, 1, 2, 3, 4, 5, 0, loc),
+		},
+		{
+			s: `<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel><item>
+				<title>a</title><dc:date>2015-01-02</dc:date>
+			</item></channel></rss>`,
+			want: time.Date(2015, 1, 2, 0, 0, 0, 0, loc),
+		},
+		{
+			s: `<rss version="2.0"><channel><item>
+				<title>a</title><pubDate>Fri, 02 Jan 2015 03:04:05 +0000</pubDate>
+			</item></channel></rss>`,
+			want: time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+	for _, v := range tab {
+		feed, err := ParseWithOptions(strings.NewReader(v.s), &ParseOptions{Location: loc})
+		if err != nil {
+			t.Errorf("ParseWithOptions(%q) = %v", v.s, err)
+			continue
+		}
+		p := feed.Articles[0]
+		tm := p.Published
+		if tm.IsZero() {
+			tm = p.Updated
+		}
+		if !tm.Equal(v.want) {
+			t.Errorf("ParseWithOptions(%q): date = %v; want %v", v.s, tm, v.want)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	s := `<rss version="2.0"><channel>
+		<title>a</title>
+		<link>/</link>
+		<description>summary&lt;script&gt;alert(1)&lt;/script&gt;</description>
+		<item>
+			<title>1</title>
+			<link>1.html</link>
+			<comments>1.html#comments</comments>
+			<description>&lt;p onclick="x()"&gt;hello&lt;/p&gt;</description>
+		</item>
+	</channel></rss>`
+	fetched := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	feed, err := ParseWithOptions(strings.NewReader(s), &ParseOptions{
+		BaseURL:   "http://example.com/blog/index.rss",
+		FetchTime: fetched,
+		Sanitize:  SanitizeSafe,
+	})
+	if err != nil {
+		t.Fatalf("ParseWithOptions = %v", err)
+	}
+	if want := "http://example.com/"; feed.URL != want {
+		t.Errorf("URL = %q; want %q", feed.URL, want)
+	}
+	if !feed.Fetched.Equal(fetched) {
+		t.Errorf("Fetched = %v; want %v", feed.Fetched, fetched)
+	}
+	if want := "summary"; feed.Summary != want {
+		t.Errorf("Summary = %q; want %q", feed.Summary, want)
+	}
+	p := feed.Articles[0]
+	if want := "http://example.com/blog/1.html"; p.URL != want {
+		t.Errorf("Articles[0].URL = %q; want %q", p.URL, want)
+	}
+	if want := "http://example.com/blog/1.html#comments"; p.Discussion.URL != want {
+		t.Errorf("Articles[0].Discussion.URL = %q; want %q", p.Discussion.URL, want)
+	}
+	if want := "<p>hello</p>"; p.Content != want {
+		t.Errorf("Articles[0].Content = %q; want %q", p.Content, want)
+	}
+}
+
+func TestParseWithOptionsLenient(t *testing.T) {
+	s := `<rss version="2.0"><channel><title>Tom &amp; Jerry&nbsp;</title></channel></rss>`
+	if _, err := ParseWithOptions(strings.NewReader(s), nil); err == nil {
+		t.Errorf("ParseWithOptions(nil) = nil; want an error")
+	}
+	feed, err := ParseWithOptions(strings.NewReader(s), &ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions(Lenient) = %v", err)
+	}
+	if want := "Tom & Jerry "; feed.Title != want {
+		t.Errorf("Title = %q; want %q", feed.Title, want)
+	}
+}
+
+func TestParseWithOptionsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := `<rss version="2.0"><channel><title>a</title></channel></rss>`
+	_, err := ParseWithOptions(strings.NewReader(s), &ParseOptions{Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseWithOptions = %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestParseOptionsNewDecoder(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	opts := &ParseOptions{
+		Location: loc,
+		Lenient:  true,
+		BaseURL:  "http://example.com/",
+		Limits:   &Limits{MaxItems: 2},
+	}
+	s := `<rss version="2.0"><channel><title>Tom &amp; Jerry&nbsp;</title>
+		<item><link>1.html</link><pubDate>Fri, 2 Jan 2015 03:04:05</pubDate></item>
+	</channel></rss>`
+	d, err := opts.NewDecoder(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("NewDecoder = %v", err)
+	}
+	raw, err := rss2.Decode(d)
+	if err != nil {
+		t.Fatalf("Decode = %v", err)
+	}
+	var feed Feed
+	if err := feed.ImportFromRSS2(raw, opts); err != nil {
+		t.Fatalf("ImportFromRSS2 = %v", err)
+	}
+	if want := "Tom & Jerry\u00a0"; feed.Title != want {
+		t.Errorf("Title = %q; want %q", feed.Title, want)
+	}
+	p := feed.Articles[0]
+	if want := time.Date(2015, 1, 2, 3, 4, 5, 0, loc); !p.Published.Equal(want) {
+		t.Errorf("Published = %v; want %v", p.Published, want)
+	}
+	if want := "http://example.com/1.html"; p.URL != want {
+		t.Errorf("URL = %q; want %q", p.URL, want)
+	}
+
+	s = `<rss version="2.0"><channel><item/><item/><item/></channel></rss>`
+	d, err = opts.NewDecoder(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("NewDecoder = %v", err)
+	}
+	var e *LimitError
+	if _, err := rss2.Decode(d); !errors.As(err, &e) || e.Name != "MaxItems" {
+		t.Errorf("Decode = %v; want MaxItems error", err)
+	}
+
+	if _, err := opts.NewDecoder(strings.NewReader(`{"version": "1"}`)); err != ErrNotXML {
+		t.Errorf("NewDecoder = %v; want %v", err, ErrNotXML)
+	}
+}
+
+func TestDetectDialectWithOptions(t *testing.T) {
+	s := `<rss version="2.0" a="1" b="2"><channel/></rss>`
+	_, err := DetectDialectWithOptions(strings.NewReader(s), &ParseOptions{
+		Limits: &Limits{MaxAttrs: 1},
+	})
+	var e *LimitError
+	if !errors.As(err, &e) || e.Name != "MaxAttrs" {
+		t.Errorf("DetectDialectWithOptions = %v; want MaxAttrs error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = DetectDialectWithOptions(strings.NewReader(s), &ParseOptions{Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DetectDialectWithOptions = %v; want %v", err, context.Canceled)
+	}
+
+	s = `<rss version="2.0"><channel><title>Tom &nbsp;</title></channel></rss>`
+	d, err := DetectDialectWithOptions(strings.NewReader(s), &ParseOptions{Lenient: true})
+	if err != nil || d != RSS2 {
+		t.Errorf("DetectDialectWithOptions = %v, %v; want %v", d, err, RSS2)
+	}
+}
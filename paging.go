@@ -0,0 +1,136 @@
+package news
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/extension"
+)
+
+// HistoryNamespace is the namespace of Feed Paging and Archiving (RFC 5005).
+const HistoryNamespace = "http://purl.org/syndication/history/1.0"
+
+// Paging is links to other documents of the feed, defined in RFC 5005.
+type Paging struct {
+	First       string
+	Last        string
+	Previous    string
+	Next        string
+	Current     string
+	PrevArchive string
+	NextArchive string
+
+	// Complete is true if the document contains all articles of the feed.
+	Complete bool
+
+	// Archive is true if the document is an archived document.
+	Archive bool
+}
+
+func (paging *Paging) setLink(rel, href string) {
+	switch rel {
+	case "first":
+		paging.First = href
+	case "last":
+		paging.Last = href
+	case "previous", "prev":
+		paging.Previous = href
+	case "next":
+		paging.Next = href
+	case "current":
+		paging.Current = href
+	case "prev-archive":
+		paging.PrevArchive = href
+	case "next-archive":
+		paging.NextArchive = href
+	}
+}
+
+func (paging *Paging) importAtom(links []atom.Link) {
+	for _, link := range links {
+		paging.setLink(link.Rel, link.URL)
+	}
+}
+
+// importExtensions reads atom:link and fh elements embedded in other dialects.
+func (paging *Paging) importExtensions(x extension.Extensions) {
+	for _, ns := range []string{atom.Namespace, atom.Namespace03} {
+		for _, e := range x.Get(ns, "link") {
+			paging.setLink(e.Attr("rel"), e.Attr("href"))
+		}
+	}
+	paging.Complete = len(x.Get(HistoryNamespace, "complete")) > 0
+	paging.Archive = len(x.Get(HistoryNamespace, "archive")) > 0
+}
+
+// MaxHistoryPages is the maximum number of documents that FetchHistory retrieves.
+const MaxHistoryPages = 1000
+
+var (
+	errPagingCycle  = errors.New("paging links form a cycle")
+	errTooManyPages = errors.New("paging links exceed MaxHistoryPages")
+)
+
+// FetchHistory reconstructs the complete history of the feed at the URL.
+// It calls fetch to retrieve documents, and follows prev-archive links,
+// or next links if the feed is paged instead of archived.
+// Articles that have the same ID are merged to the newest one.
+// It fails if the history has more than MaxHistoryPages documents.
+// The returned feed has metadata of the document at the URL.
+func FetchHistory(s string, fetch func(url string) (*Feed, error)) (*Feed, error) {
+	feed, err := fetch(s)
+	if err != nil {
+		return nil, err
+	}
+	history := *feed
+	history.Articles = nil
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	for {
+		for _, p := range feed.Articles {
+			if seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			history.Articles = append(history.Articles, p)
+		}
+		visited[s] = true
+		if feed.Paging.Complete {
+			break
+		}
+		next := feed.Paging.PrevArchive
+		if next == "" {
+			next = feed.Paging.Next
+		}
+		if next == "" {
+			break
+		}
+		if next, err = resolveURL(s, next); err != nil {
+			return nil, err
+		}
+		if visited[next] {
+			return nil, errPagingCycle
+		}
+		if len(visited) >= MaxHistoryPages {
+			return nil, errTooManyPages
+		}
+		s = next
+		if feed, err = fetch(s); err != nil {
+			return nil, err
+		}
+	}
+	return &history, nil
+}
+
+func resolveURL(base, ref string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := u.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
@@ -0,0 +1,133 @@
+package news
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParsePaging(t *testing.T) {
+	tab := []struct {
+		XMLString string
+		Want      Paging
+	}{
+		{
+			XMLString: `<?xml version="1.0"?>
+				<feed xmlns="http://www.w3.org/2005/Atom" xmlns:fh="http://purl.org/syndication/history/1.0">
+					<title>Example</title>
+					<link rel="self" href="http://example.org/2003/11/index.atom"/>
+					<link rel="current" href="http://example.org/index.atom"/>
+					<link rel="prev-archive" href="http://example.org/2003/10/index.atom"/>
+					<link rel="next-archive" href="http://example.org/2003/12/index.atom"/>
+					<fh:archive/>
+				</feed>`,
+			Want: Paging{
+				Current:     "http://example.org/index.atom",
+				PrevArchive: "http://example.org/2003/10/index.atom",
+				NextArchive: "http://example.org/2003/12/index.atom",
+				Archive:     true,
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rss version="2.0"
+					xmlns:atom="http://www.w3.org/2005/Atom"
+					xmlns:fh="http://purl.org/syndication/history/1.0">
+					<channel>
+						<title>Example</title>
+						<atom:link rel="first" href="http://example.org/index.rss"/>
+						<atom:link rel="next" href="http://example.org/index.rss?page=2"/>
+						<fh:complete/>
+					</channel>
+				</rss>`,
+			Want: Paging{
+				First:    "http://example.org/index.rss",
+				Next:     "http://example.org/index.rss?page=2",
+				Complete: true,
+			},
+		},
+	}
+	for _, v := range tab {
+		feed, err := Parse(strings.NewReader(v.XMLString))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", v.XMLString, err)
+			continue
+		}
+		if feed.Paging != v.Want {
+			t.Errorf("Parse(%q).Paging = %+v; want %+v", v.XMLString, feed.Paging, v.Want)
+		}
+	}
+}
+
+func fetchFromMap(docs map[string]*Feed) func(url string) (*Feed, error) {
+	return func(url string) (*Feed, error) {
+		feed, ok := docs[url]
+		if !ok {
+			return nil, fmt.Errorf("%s: not found", url)
+		}
+		return feed, nil
+	}
+}
+
+func articleIDs(a []*Article) []string {
+	ids := make([]string, len(a))
+	for i, p := range a {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestFetchHistory(t *testing.T) {
+	docs := map[string]*Feed{
+		"http://example.org/index.atom": {
+			Title:    "current",
+			Paging:   Paging{PrevArchive: "2003/11/index.atom"},
+			Articles: []*Article{{ID: "5"}, {ID: "4"}},
+		},
+		"http://example.org/2003/11/index.atom": {
+			Paging:   Paging{PrevArchive: "../10/index.atom", Archive: true},
+			Articles: []*Article{{ID: "4"}, {ID: "3"}},
+		},
+		"http://example.org/2003/10/index.atom": {
+			Paging:   Paging{Archive: true},
+			Articles: []*Article{{ID: "2"}, {ID: "1"}},
+		},
+	}
+	feed, err := FetchHistory("http://example.org/index.atom", fetchFromMap(docs))
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if feed.Title != "current" {
+		t.Errorf("Title = %q; want %q", feed.Title, "current")
+	}
+	ids := strings.Join(articleIDs(feed.Articles), ",")
+	if want := "5,4,3,2,1"; ids != want {
+		t.Errorf("Articles = %s; want %s", ids, want)
+	}
+}
+
+func TestFetchHistoryCycle(t *testing.T) {
+	docs := map[string]*Feed{
+		"http://example.org/1": {Paging: Paging{Next: "/2"}},
+		"http://example.org/2": {Paging: Paging{Next: "/1"}},
+	}
+	_, err := FetchHistory("http://example.org/1", fetchFromMap(docs))
+	if err != errPagingCycle {
+		t.Errorf("FetchHistory = %v; want %v", err, errPagingCycle)
+	}
+}
+
+func TestFetchHistoryTooManyPages(t *testing.T) {
+	var n int
+	fetch := func(url string) (*Feed, error) {
+		n++
+		return &Feed{Paging: Paging{Next: fmt.Sprintf("/%d", n)}}, nil
+	}
+	_, err := FetchHistory("http://example.org/0", fetch)
+	if err != errTooManyPages {
+		t.Errorf("FetchHistory = %v; want %v", err, errTooManyPages)
+	}
+	if n != MaxHistoryPages {
+		t.Errorf("fetched %d pages; want %d", n, MaxHistoryPages)
+	}
+}
@@ -0,0 +1,145 @@
+package news
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lufia/news/rss2"
+	"github.com/lufia/news/syndication"
+)
+
+// DefaultUpdateInterval is the interval used by NextFetch
+// when a feed has no hints about its update frequency.
+const DefaultUpdateInterval = time.Hour
+
+// UpdatePolicy is a hint for scheduling when a feed should be fetched next.
+type UpdatePolicy struct {
+	// Interval is the minimum duration between fetches.
+	// Zero means the feed has no hints.
+	Interval time.Duration
+
+	// Base is the time which the updates of the feed are aligned to.
+	Base time.Time
+
+	// SkipHours holds hours in UTC that the feed should not be fetched.
+	SkipHours []int
+
+	// SkipDays holds days in UTC that the feed should not be fetched.
+	SkipDays []time.Weekday
+
+	// Expires is the time until the fetched document is fresh.
+	Expires time.Time
+}
+
+// NextFetch returns the time that the feed should be fetched next after the time after.
+// If SkipHours and SkipDays skip every hour, NextFetch ignores them.
+func (policy *UpdatePolicy) NextFetch(after time.Time) time.Time {
+	d := policy.Interval
+	if d <= 0 {
+		d = DefaultUpdateInterval
+	}
+	t := after.Add(d)
+	if !policy.Base.IsZero() && policy.Interval > 0 {
+		// the floor of the number of intervals, even if after is before Base.
+		diff := after.Sub(policy.Base)
+		n := diff / d
+		if diff%d < 0 {
+			n--
+		}
+		t = policy.Base.Add((n + 1) * d)
+	}
+	if policy.Expires.After(t) {
+		t = policy.Expires
+	}
+
+	if policy.skipsAll() {
+		return t
+	}
+	// skipHours and skipDays are limited to 24 hours and 7 days,
+	// so we will get out of skipped hours within a week.
+	for i := 0; i < 24*7 && policy.skipped(t); i++ {
+		t = t.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
+
+// skipsAll reports whether SkipHours or SkipDays covers all hours or all days.
+func (policy *UpdatePolicy) skipsAll() bool {
+	hours := make(map[int]bool)
+	for _, h := range policy.SkipHours {
+		hours[h] = true
+	}
+	days := make(map[time.Weekday]bool)
+	for _, w := range policy.SkipDays {
+		days[w] = true
+	}
+	return len(hours) >= 24 || len(days) >= 7
+}
+
+func (policy *UpdatePolicy) skipped(t time.Time) bool {
+	t = t.UTC()
+	for _, h := range policy.SkipHours {
+		if t.Hour() == h {
+			return true
+		}
+	}
+	for _, w := range policy.SkipDays {
+		if t.Weekday() == w {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyHTTPHeader updates policy with Cache-Control and Expires in h.
+// The now is the time that the response was received.
+func (policy *UpdatePolicy) ApplyHTTPHeader(h http.Header, now time.Time) {
+	for _, s := range strings.Split(h.Get("Cache-Control"), ",") {
+		s = strings.TrimSpace(s)
+		switch {
+		case s == "no-cache" || s == "no-store":
+			return
+		case strings.HasPrefix(s, "max-age="):
+			n, err := strconv.Atoi(strings.TrimPrefix(s, "max-age="))
+			if err != nil || n < 0 {
+				continue
+			}
+			policy.Expires = now.Add(time.Duration(n) * time.Second)
+			return
+		}
+	}
+	if s := h.Get("Expires"); s != "" {
+		t, err := http.ParseTime(s)
+		if err == nil {
+			policy.Expires = t
+		}
+	}
+}
+
+func (policy *UpdatePolicy) importSyndication(u *syndication.Update) {
+	if d := u.Interval(); d > policy.Interval {
+		policy.Interval = d
+	}
+	policy.Base = time.Time(u.Base)
+}
+
+func (policy *UpdatePolicy) importRSS2(c *rss2.Channel) {
+	if d := time.Duration(c.TTL) * time.Minute; d > policy.Interval {
+		policy.Interval = d
+	}
+	for _, h := range c.SkipHours {
+		if h >= 0 && h < 24 {
+			policy.SkipHours = append(policy.SkipHours, h)
+		}
+	}
+	for _, s := range c.SkipDays {
+		s = strings.TrimSpace(s)
+		for w := time.Sunday; w <= time.Saturday; w++ {
+			if strings.EqualFold(s, w.String()) {
+				policy.SkipDays = append(policy.SkipDays, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package news
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdatePolicyNextFetch(t *testing.T) {
+	after := time.Date(2015, 1, 5, 10, 20, 0, 0, time.UTC) // Monday
+	tab := []struct {
+		Policy UpdatePolicy
+		Want   time.Time
+	}{
+		{
+			Policy: UpdatePolicy{},
+			Want:   after.Add(DefaultUpdateInterval),
+		},
+		{
+			Policy: UpdatePolicy{Interval: 30 * time.Minute},
+			Want:   after.Add(30 * time.Minute),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval: time.Hour,
+				Base:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			Want: time.Date(2015, 1, 5, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			// after is slightly before Base.
+			Policy: UpdatePolicy{
+				Interval: time.Hour,
+				Base:     after.Add(time.Minute),
+			},
+			Want: after.Add(time.Minute),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval: time.Hour,
+				Base:     after.Add(90 * time.Minute),
+			},
+			Want: after.Add(30 * time.Minute),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval:  time.Hour,
+				SkipHours: []int{11, 12},
+			},
+			Want: time.Date(2015, 1, 5, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval: 24 * time.Hour,
+				SkipDays: []time.Weekday{time.Tuesday},
+			},
+			Want: time.Date(2015, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval:  time.Hour,
+				SkipHours: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+			},
+			Want: after.Add(time.Hour),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval: time.Hour,
+				SkipDays: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+			},
+			Want: after.Add(time.Hour),
+		},
+		{
+			Policy: UpdatePolicy{
+				Interval: time.Hour,
+				Expires:  after.Add(3 * time.Hour),
+			},
+			Want: after.Add(3 * time.Hour),
+		},
+	}
+	for _, v := range tab {
+		if next := v.Policy.NextFetch(after); !next.Equal(v.Want) {
+			t.Errorf("(%+v).NextFetch(%v) = %v; want %v", v.Policy, after, next, v.Want)
+		}
+	}
+}
+
+func TestUpdatePolicyApplyHTTPHeader(t *testing.T) {
+	now := time.Date(2015, 1, 5, 10, 0, 0, 0, time.UTC)
+	tab := []struct {
+		Header http.Header
+		Want   time.Time
+	}{
+		{
+			Header: http.Header{"Cache-Control": {"public, max-age=600"}},
+			Want:   now.Add(10 * time.Minute),
+		},
+		{
+			Header: http.Header{"Expires": {"Mon, 05 Jan 2015 12:00:00 GMT"}},
+			Want:   time.Date(2015, 1, 5, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Header: http.Header{
+				"Cache-Control": {"no-cache"},
+				"Expires":       {"Mon, 05 Jan 2015 12:00:00 GMT"},
+			},
+		},
+	}
+	for _, v := range tab {
+		var policy UpdatePolicy
+		policy.ApplyHTTPHeader(v.Header, now)
+		if !policy.Expires.Equal(v.Want) {
+			t.Errorf("ApplyHTTPHeader(%v): Expires = %v; want %v", v.Header, policy.Expires, v.Want)
+		}
+	}
+}
+
+func TestParseUpdatePolicy(t *testing.T) {
+	tab := []struct {
+		XMLString string
+		Want      UpdatePolicy
+	}{
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rss version="2.0">
+					<channel>
+						<title>Example</title>
+						<ttl>60</ttl>
+						<skipHours><hour>0</hour><hour>24</hour></skipHours>
+						<skipDays><day>Sunday</day></skipDays>
+					</channel>
+				</rss>`,
+			Want: UpdatePolicy{
+				Interval:  time.Hour,
+				SkipHours: []int{0},
+				SkipDays:  []time.Weekday{time.Sunday},
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rdf:RDF xmlns="http://purl.org/rss/1.0/"
+					xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+					xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+					<channel rdf:about="http://example.com/rss">
+						<title>Example</title>
+						<sy:updatePeriod>hourly</sy:updatePeriod>
+						<sy:updateFrequency>2</sy:updateFrequency>
+						<sy:updateBase>2000-01-01T00:00:00Z</sy:updateBase>
+					</channel>
+				</rdf:RDF>`,
+			Want: UpdatePolicy{
+				Interval: 30 * time.Minute,
+				Base:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			XMLString: `<?xml version="1.0"?>
+				<rdf:RDF xmlns="http://purl.org/rss/1.0/"
+					xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+					xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+					<channel rdf:about="http://example.com/rss">
+						<title>Example</title>
+						<sy:updatePeriod>daily</sy:updatePeriod>
+						<sy:updateBase>the first day</sy:updateBase>
+					</channel>
+				</rdf:RDF>`,
+			Want: UpdatePolicy{
+				Interval: 24 * time.Hour,
+			},
+		},
+	}
+	for _, v := range tab {
+		feed, err := Parse(strings.NewReader(v.XMLString))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", v.XMLString, err)
+			continue
+		}
+		if !reflect.DeepEqual(feed.UpdatePolicy, v.Want) {
+			t.Errorf("Parse(%q).UpdatePolicy = %+v; want %+v", v.XMLString, feed.UpdatePolicy, v.Want)
+		}
+	}
+}
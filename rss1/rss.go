@@ -3,39 +3,97 @@ package rss1
 import (
 	"encoding/xml"
 	"io"
-	"time"
+
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/limit"
+)
+
+const (
+	// Namespace is the namespace of RSS 1.0.
+	Namespace = "http://purl.org/rss/1.0/"
+
+	// RDFNamespace is the namespace of RDF.
+	RDFNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
 )
 
 type Feed struct {
 	XMLName xml.Name `xml:"RDF"`
 	Channel *Channel `xml:"channel"`
+	Image   *Image   `xml:"image"`
 	Items   []*Item  `xml:"item"`
+
+	Extensions []extension.Element `xml:",any"`
 }
 
 type Channel struct {
-	Title       string    `xml:"title"`
-	Link        string    `xml:"link"`
-	Description string    `xml:"description"`
-	Date        time.Time `xml:"date"`
-	Language    string    `xml:"language"`
-	Indexes     []*Index  `xml:"items>Seq>li"`
+	About       string   `xml:"about,attr"` // rdf:about
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Indexes     []*Index `xml:"items>Seq>li"`
+
+	Extensions []extension.Element `xml:",any"`
 }
 
 type Index struct {
 	URL string `xml:"resource,attr"`
 }
 
+type Image struct {
+	About string `xml:"about,attr"` // rdf:about
+	Title string `xml:"title"`
+	URL   string `xml:"url"`
+	Link  string `xml:"link"`
+}
+
 type Item struct {
-	Title       string    `xml:"title"`
-	Link        string    `xml:"link"`
-	Description string    `xml:"description"`
-	Creator     string    `xml:"creator"`
-	Date        time.Time `xml:"date"`
+	About       string `xml:"about,attr"` // rdf:about
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+
+	Extensions []extension.Element `xml:",any"`
+}
+
+// Extension returns the value decoded from elements in the namespace ns
+// by the decoder registered in the extension package.
+func (c *Channel) Extension(ns string) (interface{}, error) {
+	return extension.New(c.Extensions).Decode(ns)
+}
+
+// Extension returns the value decoded from elements in the namespace ns
+// by the decoder registered in the extension package.
+func (item *Item) Extension(ns string) (interface{}, error) {
+	return extension.New(item.Extensions).Decode(ns)
+}
+
+// isNative reports whether name is an element of RSS 1.0.
+func isNative(name xml.Name) bool {
+	switch name.Space {
+	case "", Namespace, RDFNamespace:
+		return true
+	}
+	return false
+}
+
+func (c *Channel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Channel
+	return extension.DecodeElement(d, (*plain)(c), &start, isNative, &c.Extensions)
+}
+
+func (item *Item) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Item
+	return extension.DecodeElement(d, (*plain)(item), &start, isNative, &item.Extensions)
 }
 
+// Parse parses a RSS 1.0 document from r within limit.Default.
 func Parse(r io.Reader) (feed *Feed, err error) {
+	return Decode(limit.Default.NewDecoder(r))
+}
+
+// Decode decodes a feed from d.
+func Decode(d *xml.Decoder) (feed *Feed, err error) {
 	var x Feed
-	d := xml.NewDecoder(r)
 	err = d.Decode(&x)
 	if err != nil {
 		return
@@ -43,3 +101,14 @@ func Parse(r io.Reader) (feed *Feed, err error) {
 	feed = &x
 	return
 }
+
+// DecodeElement decodes a feed from d; start is the root element that was already read from d.
+func DecodeElement(d *xml.Decoder, start *xml.StartElement) (feed *Feed, err error) {
+	var x Feed
+	err = d.DecodeElement(&x, start)
+	if err != nil {
+		return
+	}
+	feed = &x
+	return
+}
@@ -2,8 +2,11 @@ package rss1
 
 import (
 	"encoding/xml"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/lufia/news/limit"
 )
 
 func TestParse(t *testing.T) {
@@ -75,3 +78,19 @@ var xmlStringSimple = strings.TrimSpace(`
 	</item>
 </rdf:RDF>
 `)
+
+func FuzzParse(f *testing.F) {
+	f.Add(xmlStringSimple)
+	f.Fuzz(func(t *testing.T, s string) {
+		Parse(strings.NewReader(s))
+	})
+}
+
+func TestParseLimits(t *testing.T) {
+	s := `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel>` + strings.Repeat("<a>", limit.Default.MaxDepth) + strings.Repeat("</a>", limit.Default.MaxDepth) + `</channel></rdf:RDF>`
+	_, err := Parse(strings.NewReader(s))
+	var e *limit.Error
+	if !errors.As(err, &e) || e.Name != "MaxDepth" {
+		t.Errorf("Parse = %v; want MaxDepth error", err)
+	}
+}
@@ -4,18 +4,28 @@ import (
 	"encoding/xml"
 	"errors"
 	"io"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/extension"
+	"github.com/lufia/news/limit"
 )
 
 type Date time.Time
 
+// ContentNamespace is the namespace of the content module that defines content:encoded.
+const ContentNamespace = "http://purl.org/rss/1.0/modules/content/"
+
 const (
 	RFC2822  = "Mon, _2 Jan 2006 15:04:05 -0700"
 	RFC2822Z = "Mon, _2 Jan 2006 15:04:05 MST"
 )
 
 var (
-	errNoItemID = errors.New("item hasn't <guid> or <link> tag")
+	// ErrNoItemID is returned by Item.ID if the item has neither guid nor link.
+	ErrNoItemID = errors.New("item hasn't <guid> or <link> tag")
 )
 
 func (date Date) String() string {
@@ -45,31 +55,126 @@ type Feed struct {
 }
 
 type Channel struct {
-	Title         string   `xml:"title"`
-	Link          string   `xml:"link"`
-	Description   string   `xml:"description"`
-	Language      string   `xml:"language,omitempty"`
-	LastBuildDate Date     `xml:"lastBuildDate,omitempty"`
-	Category      Category `xml:"category,omitempty"`
-	Items         []*Item  `xml:"item"`
+	// AtomLinks are atom:link elements such as rel="self" and rel="hub".
+	// They are not in Extensions; the news package imports them into Feed.Links.
+	// It must precede Link, or Link will take atom:link elements too.
+	AtomLinks []atom.Link `xml:"http://www.w3.org/2005/Atom link,omitempty"`
+
+	Title          string     `xml:"title"`
+	Link           string     `xml:"link"`
+	Description    string     `xml:"description"`
+	Language       string     `xml:"language,omitempty"`
+	Copyright      string     `xml:"copyright,omitempty"`
+	ManagingEditor string     `xml:"managingEditor,omitempty"` // editor's email address
+	WebMaster      string     `xml:"webMaster,omitempty"`      // webmaster's email address
+	PubDate        Date       `xml:"pubDate,omitempty"`
+	LastBuildDate  Date       `xml:"lastBuildDate,omitempty"`
+	Categories     []Category `xml:"category,omitempty"`
+	Generator      string     `xml:"generator,omitempty"`
+	Docs           string     `xml:"docs,omitempty"`
+	Cloud          *Cloud     `xml:"cloud,omitempty"`
+	TTL            TTL        `xml:"ttl,omitempty"`
+	Image          *Image     `xml:"image,omitempty"`
+	Rating         string     `xml:"rating,omitempty"` // PICS rating
+	TextInput      *TextInput `xml:"textInput,omitempty"`
+	SkipHours      []int      `xml:"skipHours>hour,omitempty"` // hours that are not numbers are ignored
+	SkipDays       []string   `xml:"skipDays>day,omitempty"`
+	Items          []*Item    `xml:"item"`
+
+	Extensions []extension.Element `xml:",any"`
+}
+
+// TTL is the number of minutes that the channel can be cached before refreshing.
+// It is zero, that means no hint, if the value is not a non-negative number.
+type TTL int
+
+func (ttl *TTL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		n = 0
+	}
+	*ttl = TTL(n)
+	return nil
+}
+
+// Image is a GIF, JPEG or PNG image that can be displayed with the channel.
+type Image struct {
+	URL         string `xml:"url"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Width       int    `xml:"width,omitempty"`  // default 88, maximum 144
+	Height      int    `xml:"height,omitempty"` // default 31, maximum 400
+	Description string `xml:"description,omitempty"`
+}
+
+// Cloud is a web service that supports the rssCloud interface.
+type Cloud struct {
+	Domain            string `xml:"domain,attr"`
+	Port              int    `xml:"port,attr"`
+	Path              string `xml:"path,attr"`
+	RegisterProcedure string `xml:"registerProcedure,attr"`
+	Protocol          string `xml:"protocol,attr"`
+}
+
+// TextInput is a text input box that can be displayed with the channel.
+type TextInput struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Name        string `xml:"name"`
+	Link        string `xml:"link"`
+}
+
+// Enclosure is a media object that is attached to the item.
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"` // bytes; zero if the value is not a non-negative number
+	Type   string `xml:"type,attr"`
+}
+
+func (e *Enclosure) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var x struct {
+		URL    string `xml:"url,attr"`
+		Length string `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	}
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(x.Length), 10, 64)
+	if err != nil || n < 0 {
+		n = 0
+	}
+	e.URL = x.URL
+	e.Length = n
+	e.Type = x.Type
+	return nil
+}
 
-	Creator string    `xml:"creator"` // dc:creator
-	Date    time.Time `xml:"date"`    // dc:date
+// Source is the RSS channel that the item came from.
+type Source struct {
+	URL   string `xml:"url,attr"`
+	Title string `xml:",chardata"`
 }
 
 type Item struct {
 	Title       string     `xml:"title,omitempty"`
 	Link        string     `xml:"link,omitempty"`
 	Description string     `xml:"description,omitempty"`
-	Author      string     `xml:"author,omitempty"` // author's email address
+	Author      string     `xml:"author,omitempty"`   // author's email address
+	Comments    string     `xml:"comments,omitempty"` // URL of the comments page
 	Categories  []Category `xml:"category,omitempty"`
-	Guid        Guid       `xml:"guid,omietmpty"`
+	Enclosure   *Enclosure `xml:"enclosure,omitempty"`
+	Guid        Guid       `xml:"guid,omitempty"`
 	PubDate     Date       `xml:"pubDate,omitempty"`
+	Source      *Source    `xml:"source,omitempty"`
 
-	Subject string    `xml:"subject,omitempty"` // dc:subject
-	Creator string    `xml:"creator,omitempty"` // dc:creator
-	Date    time.Time `xml:"date,omitempty"`    // dc:date
-	Encoded string    `xml:"encoded,omitempty"` // content:encoded
+	Encoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded,omitempty"` // content:encoded
+
+	Extensions []extension.Element `xml:",any"`
 }
 
 func (item *Item) Content() string {
@@ -79,14 +184,24 @@ func (item *Item) Content() string {
 	return item.Description
 }
 
+// ID returns the guid of the item, or its link if the item has no guid.
 func (item *Item) ID() (string, error) {
-	if item.Guid.IsPermaLink && item.Guid.Content != "" {
+	if item.Guid.Content != "" {
 		return item.Guid.Content, nil
 	}
 	if item.Link != "" {
 		return item.Link, nil
 	}
-	return "", errNoItemID
+	return "", ErrNoItemID
+}
+
+// Permalink returns the guid of the item if it is a permalink,
+// otherwise returns its link.
+func (item *Item) Permalink() string {
+	if item.Guid.IsPermaLink && item.Guid.Content != "" {
+		return item.Guid.Content
+	}
+	return item.Link
 }
 
 type Category struct {
@@ -94,14 +209,86 @@ type Category struct {
 	Content string `xml:",chardata"`
 }
 
+// Guid is a string that uniquely identifies the item.
+// IsPermaLink is true unless the isPermaLink attribute is "false".
 type Guid struct {
-	IsPermaLink bool   `xml:"isPermaLink,omitempty"`
-	Content     string `xml:",chardata"`
+	IsPermaLink bool
+	Content     string
+}
+
+func (guid *Guid) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	var x struct {
+		IsPermaLink string `xml:"isPermaLink,attr"`
+		Content     string `xml:",chardata"`
+	}
+	err = d.DecodeElement(&x, &start)
+	if err != nil {
+		return
+	}
+	guid.IsPermaLink = strings.TrimSpace(x.IsPermaLink) != "false"
+	guid.Content = strings.TrimSpace(x.Content)
+	return
+}
+
+// Extension returns the value decoded from elements in the namespace ns
+// by the decoder registered in the extension package.
+func (c *Channel) Extension(ns string) (interface{}, error) {
+	return extension.New(c.Extensions).Decode(ns)
+}
+
+// Extension returns the value decoded from elements in the namespace ns
+// by the decoder registered in the extension package.
+func (item *Item) Extension(ns string) (interface{}, error) {
+	return extension.New(item.Extensions).Decode(ns)
+}
+
+// isNative reports whether name is an element of RSS 2.0.
+func isNative(name xml.Name) bool {
+	return name.Space == ""
+}
+
+// isItemElement reports whether name is decoded into a field of Item.
+func isItemElement(name xml.Name) bool {
+	return isNative(name) || name.Space == ContentNamespace && name.Local == "encoded"
+}
+
+// isChannelElement reports whether name is decoded into a field of Channel.
+func isChannelElement(name xml.Name) bool {
+	return isNative(name) || name.Space == atom.Namespace && name.Local == "link"
+}
+
+func (c *Channel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Channel
+	// SkipHours of x shadows the one of plain, so a malformed hour
+	// does not fail the whole channel.
+	x := struct {
+		*plain
+		SkipHours []string `xml:"skipHours>hour,omitempty"`
+	}{plain: (*plain)(c)}
+	if err := extension.DecodeElement(d, &x, &start, isChannelElement, &c.Extensions); err != nil {
+		return err
+	}
+	for _, s := range x.SkipHours {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			c.SkipHours = append(c.SkipHours, n)
+		}
+	}
+	return nil
 }
 
+func (item *Item) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Item
+	return extension.DecodeElement(d, (*plain)(item), &start, isItemElement, &item.Extensions)
+}
+
+// Parse parses a RSS 2.0 document from r within limit.Default.
 func Parse(r io.Reader) (feed *Feed, err error) {
+	return Decode(limit.Default.NewDecoder(r))
+}
+
+// Decode decodes a feed from d.
+func Decode(d *xml.Decoder) (feed *Feed, err error) {
 	var x Feed
-	d := xml.NewDecoder(r)
 	err = d.Decode(&x)
 	if err != nil {
 		return
@@ -109,3 +296,14 @@ func Parse(r io.Reader) (feed *Feed, err error) {
 	feed = &x
 	return
 }
+
+// DecodeElement decodes a feed from d; start is the root element that was already read from d.
+func DecodeElement(d *xml.Decoder, start *xml.StartElement) (feed *Feed, err error) {
+	var x Feed
+	err = d.DecodeElement(&x, start)
+	if err != nil {
+		return
+	}
+	feed = &x
+	return
+}
@@ -2,8 +2,14 @@ package rss2
 
 import (
 	"encoding/xml"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/limit"
 )
 
 func TestParse(t *testing.T) {
@@ -64,3 +70,256 @@ var xmlStringSimple = strings.TrimSpace(`
 	</channel>
 </rss>
 `)
+
+func TestParseChannel(t *testing.T) {
+	r := strings.NewReader(xmlStringChannel)
+	feed, err := Parse(r)
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", xmlStringChannel, err)
+	}
+	c := feed.Channel
+	if c.Copyright != "Copyright 2008 Example" {
+		t.Errorf("Copyright = %q", c.Copyright)
+	}
+	if c.ManagingEditor != "editor@example.com" {
+		t.Errorf("ManagingEditor = %q", c.ManagingEditor)
+	}
+	if c.TTL != 60 {
+		t.Errorf("TTL = %d; want 60", c.TTL)
+	}
+	if len(c.Categories) != 2 {
+		t.Errorf("len(Categories) = %d; want 2", len(c.Categories))
+	}
+	if c.Image == nil || c.Image.URL != "http://example.com/logo.png" || c.Image.Width != 88 {
+		t.Errorf("Image = %v", c.Image)
+	}
+	if c.Cloud == nil || c.Cloud.Port != 80 || c.Cloud.Protocol != "xml-rpc" {
+		t.Errorf("Cloud = %v", c.Cloud)
+	}
+	if c.TextInput == nil || c.TextInput.Name != "q" {
+		t.Errorf("TextInput = %v", c.TextInput)
+	}
+	if !reflect.DeepEqual(c.SkipHours, []int{0, 1}) {
+		t.Errorf("SkipHours = %v", c.SkipHours)
+	}
+	if !reflect.DeepEqual(c.SkipDays, []string{"Saturday", "Sunday"}) {
+		t.Errorf("SkipDays = %v", c.SkipDays)
+	}
+	want := time.Date(2008, 6, 10, 4, 0, 0, 0, time.UTC)
+	if !time.Time(c.PubDate).Equal(want) {
+		t.Errorf("PubDate = %v; want %v", c.PubDate, want)
+	}
+}
+
+func TestParseChannelAtomLinks(t *testing.T) {
+	s := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+		<channel>
+			<title>Example</title>
+			<link>http://example.com/</link>
+			<atom:link rel="self" type="application/rss+xml" href="http://example.com/feed"/>
+			<atom:link rel="hub" href="http://hub.example.com/"/>
+		</channel>
+	</rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", s, err)
+	}
+	c := feed.Channel
+	if c.Link != "http://example.com/" {
+		t.Errorf("Link = %q; want %q", c.Link, "http://example.com/")
+	}
+	want := []atom.Link{
+		{Rel: "self", Type: "application/rss+xml", URL: "http://example.com/feed"},
+		{Rel: "hub", URL: "http://hub.example.com/"},
+	}
+	if !reflect.DeepEqual(c.AtomLinks, want) {
+		t.Errorf("AtomLinks = %+v; want %+v", c.AtomLinks, want)
+	}
+	if len(c.Extensions) != 0 {
+		t.Errorf("Extensions = %v; want empty", c.Extensions)
+	}
+}
+
+func TestParseChannelTTL(t *testing.T) {
+	tab := []struct {
+		S    string
+		Want TTL
+	}{
+		{S: "60", Want: 60},
+		{S: " 30 ", Want: 30},
+		{S: "sixty", Want: 0},
+		{S: "-1", Want: 0},
+	}
+	for _, v := range tab {
+		s := `<rss version="2.0"><channel><title>t</title><ttl>` + v.S + `</ttl></channel></rss>`
+		feed, err := Parse(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", s, err)
+			continue
+		}
+		if ttl := feed.Channel.TTL; ttl != v.Want {
+			t.Errorf("Parse(%q): TTL = %d; want %d", s, ttl, v.Want)
+		}
+	}
+}
+
+func TestParseChannelSkipHours(t *testing.T) {
+	s := `<rss version="2.0"><channel><title>t</title>
+		<skipHours><hour>1</hour><hour>noon</hour><hour></hour><hour> 23 </hour></skipHours>
+	</channel></rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	if hours := feed.Channel.SkipHours; !reflect.DeepEqual(hours, []int{1, 23}) {
+		t.Errorf("SkipHours = %v; want [1 23]", hours)
+	}
+}
+
+func TestParseEnclosureLength(t *testing.T) {
+	tab := []struct {
+		S    string
+		Want int64
+	}{
+		{S: "1024", Want: 1024},
+		{S: " 12 ", Want: 12},
+		{S: "", Want: 0},
+		{S: "unknown", Want: 0},
+		{S: "-1", Want: 0},
+	}
+	for _, v := range tab {
+		s := `<rss version="2.0"><channel><title>t</title><item>` +
+			`<enclosure url="http://example.com/a.mp3" length="` + v.S + `" type="audio/mpeg"/>` +
+			`</item></channel></rss>`
+		feed, err := Parse(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", s, err)
+			continue
+		}
+		want := Enclosure{URL: "http://example.com/a.mp3", Length: v.Want, Type: "audio/mpeg"}
+		if e := feed.Channel.Items[0].Enclosure; e == nil || *e != want {
+			t.Errorf("Parse(%q): Enclosure = %+v; want %+v", s, e, want)
+		}
+	}
+}
+
+func TestParseItemEncoded(t *testing.T) {
+	s := `<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+		<channel>
+			<item>
+				<description>summary</description>
+				<content:encoded>full content</content:encoded>
+			</item>
+		</channel>
+	</rss>`
+	feed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", s, err)
+	}
+	item := feed.Channel.Items[0]
+	if item.Encoded != "full content" {
+		t.Errorf("Encoded = %q; want %q", item.Encoded, "full content")
+	}
+	if len(item.Extensions) != 0 {
+		t.Errorf("Extensions = %v; want empty", item.Extensions)
+	}
+}
+
+var xmlStringChannel = strings.TrimSpace(`
+<?xml version='1.0' encoding='UTF-8'?>
+<rss version='2.0'>
+	<channel>
+		<title>Example</title>
+		<link>http://example.com/</link>
+		<description>Example channel</description>
+		<copyright>Copyright 2008 Example</copyright>
+		<managingEditor>editor@example.com</managingEditor>
+		<webMaster>webmaster@example.com</webMaster>
+		<pubDate>Tue, 10 Jun 2008 04:00:00 +0000</pubDate>
+		<category>News</category>
+		<category domain="http://example.com/tags">Tech</category>
+		<generator>Example Generator 1.0</generator>
+		<docs>http://www.rssboard.org/rss-specification</docs>
+		<cloud domain="rpc.example.com" port="80" path="/RPC2" registerProcedure="pingMe" protocol="xml-rpc"/>
+		<ttl>60</ttl>
+		<image>
+			<url>http://example.com/logo.png</url>
+			<title>Example</title>
+			<link>http://example.com/</link>
+			<width>88</width>
+			<height>31</height>
+		</image>
+		<rating>(PICS-1.1 "http://www.rsac.org/ratingsv01.html" l by "webmaster@example.com" r (n 0 s 0 v 0 l 0))</rating>
+		<textInput>
+			<title>Search</title>
+			<description>Search this site</description>
+			<name>q</name>
+			<link>http://example.com/search</link>
+		</textInput>
+		<skipHours><hour>0</hour><hour>1</hour></skipHours>
+		<skipDays><day>Saturday</day><day>Sunday</day></skipDays>
+	</channel>
+</rss>
+`)
+
+func TestItemID(t *testing.T) {
+	tab := []struct {
+		XMLString string
+		ID        string
+		Permalink string
+		Err       bool
+	}{
+		{
+			XMLString: `<item><guid>http://example.com/1</guid><link>http://example.com/a</link></item>`,
+			ID:        "http://example.com/1",
+			Permalink: "http://example.com/1",
+		},
+		{
+			XMLString: `<item><guid isPermaLink="false">tag:example.com,2008:1</guid><link>http://example.com/a</link></item>`,
+			ID:        "tag:example.com,2008:1",
+			Permalink: "http://example.com/a",
+		},
+		{
+			XMLString: `<item><link>http://example.com/a</link></item>`,
+			ID:        "http://example.com/a",
+			Permalink: "http://example.com/a",
+		},
+		{
+			XMLString: `<item><title>no identifier</title></item>`,
+			Err:       true,
+		},
+	}
+	for _, v := range tab {
+		var item Item
+		if err := xml.Unmarshal([]byte(v.XMLString), &item); err != nil {
+			t.Fatalf("Unmarshal(%q) = %v", v.XMLString, err)
+		}
+		id, err := item.ID()
+		if (err != nil) != v.Err {
+			t.Errorf("ID() of %q = %v", v.XMLString, err)
+		}
+		if id != v.ID {
+			t.Errorf("ID() of %q = %q; want %q", v.XMLString, id, v.ID)
+		}
+		if s := item.Permalink(); s != v.Permalink {
+			t.Errorf("Permalink() of %q = %q; want %q", v.XMLString, s, v.Permalink)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(xmlStringSimple)
+	f.Add(xmlStringChannel)
+	f.Fuzz(func(t *testing.T, s string) {
+		Parse(strings.NewReader(s))
+	})
+}
+
+func TestParseLimits(t *testing.T) {
+	s := `<rss version="2.0"><channel>` + strings.Repeat("<a>", limit.Default.MaxDepth) + strings.Repeat("</a>", limit.Default.MaxDepth) + `</channel></rss>`
+	_, err := Parse(strings.NewReader(s))
+	var e *limit.Error
+	if !errors.As(err, &e) || e.Name != "MaxDepth" {
+		t.Errorf("Parse = %v; want MaxDepth error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package news
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SanitizePolicy is a policy to sanitize HTML in feeds.
+type SanitizePolicy int
+
+const (
+	// SanitizeNone leaves HTML as is.
+	SanitizeNone SanitizePolicy = iota
+
+	// SanitizeSafe removes scripts, styles, embedded contents, SVG and MathML,
+	// event handler and style attributes, and URLs of script schemes.
+	SanitizeSafe
+
+	// SanitizeText removes all tags; only texts remain.
+	// The result is still HTML; characters such as < in texts are escaped.
+	SanitizeText
+)
+
+// droppedElements are elements to be removed with their contents.
+// If the value is true, the element is a void element.
+var droppedElements = map[string]bool{
+	"script":   false,
+	"style":    false,
+	"iframe":   false,
+	"object":   false,
+	"applet":   false,
+	"noscript": false,
+	"noembed":  false,
+	"noframes": false,
+	"frameset": false,
+	"template": false,
+	"svg":      false, // animate and set can rewrite href of its children
+	"math":     false,
+	"embed":    true,
+	"frame":    true,
+	"param":    true,
+	"base":     true,
+	"meta":     true,
+	"link":     true,
+	"animate":  true,
+	"set":      true,
+}
+
+// urlAttrs are attributes that have URL as its value.
+var urlAttrs = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"background": true,
+	"poster":     true,
+	"cite":       true,
+	"xlink:href": true,
+}
+
+// Sanitize returns HTML s that is sanitized by policy.
+func (policy SanitizePolicy) Sanitize(s string) string {
+	if policy == SanitizeNone || !strings.ContainsAny(s, "<&") {
+		return s
+	}
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	var (
+		skip     int // depth of dropped element
+		skipName string
+	)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if policy == SanitizeText {
+				// z.Text is unescaped, so &lt; would turn into a tag.
+				b.WriteString(html.EscapeString(string(z.Text())))
+			} else {
+				b.Write(z.Raw())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if skip > 0 {
+				if tt == html.StartTagToken && tok.Data == skipName {
+					skip++
+				}
+				continue
+			}
+			if void, ok := droppedElements[tok.Data]; ok {
+				if !void && tt == html.StartTagToken {
+					skip, skipName = 1, tok.Data
+				}
+				continue
+			}
+			if policy == SanitizeText {
+				continue
+			}
+			tok.Attr = safeAttrs(tok.Attr)
+			b.WriteString(tok.String())
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if skip > 0 {
+				if string(name) == skipName {
+					skip--
+				}
+				continue
+			}
+			if _, ok := droppedElements[string(name)]; ok || policy == SanitizeText {
+				continue
+			}
+			b.Write(z.Raw())
+		}
+	}
+}
+
+// safeAttrs returns attributes without event handlers, styles and script URLs.
+func safeAttrs(attrs []html.Attribute) []html.Attribute {
+	a := attrs[:0]
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+		switch {
+		case strings.HasPrefix(key, "on"), key == "srcdoc", key == "style":
+			continue
+		case urlAttrs[key] && !isSafeURL(attr.Val):
+			continue
+		}
+		a = append(a, attr)
+	}
+	return a
+}
+
+// isSafeURL reports whether s is not a URL that runs scripts.
+func isSafeURL(s string) bool {
+	s = strings.ToLower(strings.Map(func(c rune) rune {
+		if c <= ' ' {
+			return -1
+		}
+		return c
+	}, s))
+	switch {
+	case strings.HasPrefix(s, "javascript:"), strings.HasPrefix(s, "vbscript:"):
+		return false
+	case strings.HasPrefix(s, "data:"):
+		return strings.HasPrefix(s, "data:image/") && !strings.HasPrefix(s, "data:image/svg")
+	}
+	return true
+}
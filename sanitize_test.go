@@ -0,0 +1,29 @@
+package news
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tab := []struct {
+		policy SanitizePolicy
+		s      string
+		want   string
+	}{
+		{SanitizeNone, `<p onclick="x()">a</p><script>x()</script>`, `<p onclick="x()">a</p><script>x()</script>`},
+		{SanitizeSafe, `plain &amp; text`, `plain &amp; text`},
+		{SanitizeSafe, `<p onclick="x()">a</p><script>x()</script>`, `<p>a</p>`},
+		{SanitizeSafe, `<a href=" javascript:x()">a</a><a href="/b">b</a>`, `<a>a</a><a href="/b">b</a>`},
+		{SanitizeSafe, `<img src="data:image/png;base64,AA=="><img src="data:text/html,x">`, `<img src="data:image/png;base64,AA=="><img>`},
+		{SanitizeSafe, `<div><object><object></object>x</object>y<iframe>z</iframe></div><meta charset="utf-8">`, `<div>y</div>`},
+		{SanitizeSafe, `<svg><animate attributeName="href" values="javascript:alert(1)"/><a><text>x</text></a></svg>y`, `y`},
+		{SanitizeSafe, `<svg><set attributeName="href" to="javascript:alert(1)"></set></svg>y`, `y`},
+		{SanitizeSafe, `<animate attributeName="href" from="javascript:alert(1)">y<set to="javascript:alert(1)">`, `y`},
+		{SanitizeSafe, `<math><mi xlink:href="javascript:alert(1)">x</mi></math><p style="background:url(javascript:x)">y</p>`, `<p>y</p>`},
+		{SanitizeText, `<p>a &amp; <b>b</b></p><style>p{}</style>`, `a &amp; b`},
+		{SanitizeText, `<p>&lt;script&gt;x()&lt;/script&gt;</p>`, `&lt;script&gt;x()&lt;/script&gt;`},
+	}
+	for _, v := range tab {
+		if s := v.policy.Sanitize(v.s); s != v.want {
+			t.Errorf("Sanitize(%q) = %q; want %q", v.s, s, v.want)
+		}
+	}
+}
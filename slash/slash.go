@@ -0,0 +1,32 @@
+// Package slash implements the RSS 1.0 Slash module.
+package slash
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/extension"
+)
+
+// Namespace is the namespace of the Slash module.
+const Namespace = "http://purl.org/rss/1.0/modules/slash/"
+
+// Slash is the set of elements in the Slash module.
+type Slash struct {
+	Section    string `xml:"http://purl.org/rss/1.0/modules/slash/ section,omitempty"`
+	Department string `xml:"http://purl.org/rss/1.0/modules/slash/ department,omitempty"`
+	Comments   int    `xml:"http://purl.org/rss/1.0/modules/slash/ comments,omitempty"`
+	HitParade  string `xml:"http://purl.org/rss/1.0/modules/slash/ hit_parade,omitempty"`
+}
+
+func init() {
+	extension.Register(Namespace, decode)
+}
+
+// decode decodes the Slash elements to *Slash.
+func decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var s Slash
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
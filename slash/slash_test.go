@@ -0,0 +1,37 @@
+package slash
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/lufia/news/extension"
+)
+
+func TestDecode(t *testing.T) {
+	s := `<item xmlns:slash="http://purl.org/rss/1.0/modules/slash/">
+		<slash:section>articles</slash:section>
+		<slash:department>example-department</slash:department>
+		<slash:comments> 42 </slash:comments>
+		<slash:hit_parade>42,40,30,20,10,5,0</slash:hit_parade>
+	</item>`
+	var v struct {
+		Extensions []extension.Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := extension.New(v.Extensions).Decode(Namespace)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", Namespace, err)
+	}
+	want := &Slash{
+		Section:    "articles",
+		Department: "example-department",
+		Comments:   42,
+		HitParade:  "42,40,30,20,10,5,0",
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Decode(%q) = %#v; want %#v", Namespace, p, want)
+	}
+}
@@ -0,0 +1,378 @@
+package news
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	// ErrEmpty is the error that the document has no content.
+	ErrEmpty = errors.New("news: empty document")
+
+	// ErrNotXML is the error that the document is not XML, such as JSON or plain text.
+	ErrNotXML = errors.New("news: document is not XML")
+
+	// ErrHTMLNotFeed is the error that the document is a HTML page.
+	// The page might link to its feeds; try feed discovery on it.
+	ErrHTMLNotFeed = errors.New("news: HTML document is not a feed; try feed discovery")
+)
+
+// SniffLen is the number of leading bytes that Sniff considers at most.
+const SniffLen = 1024
+
+// SniffResult is a guess of the media type of a document.
+type SniffResult struct {
+	MediaType  string
+	Dialect    Dialect // nil if the root element is not recognized
+	Confidence float64 // between 0 and 1
+}
+
+// Sniff guesses the media type of a document from its leading bytes p.
+// contentType is the value of HTTP Content-Type header and name is
+// the file name or URL of the document; both of them are optional.
+//
+// If the document can't be parsed as a feed, Sniff returns the guess
+// with an error such as ErrEmpty, ErrNotXML, ErrHTMLNotFeed or ErrUnknownDialect.
+func Sniff(p []byte, contentType, name string) (SniffResult, error) {
+	if len(p) > SniffLen {
+		p = p[:SniffLen]
+	}
+	hint := sniffHint(contentType, name)
+	q := bytes.TrimLeft(decodeBOM(p), " \t\r\n")
+	if len(q) == 0 {
+		return hint, ErrEmpty
+	}
+	var (
+		r   SniffResult
+		err error
+	)
+	switch q[0] {
+	case '<':
+		r, err = sniffMarkup(q)
+	case '{', '[':
+		r = SniffResult{MediaType: "application/json", Confidence: 0.8}
+		if bytes.Contains(q, []byte("https://jsonfeed.org/version/")) {
+			r = SniffResult{MediaType: "application/feed+json", Confidence: 0.9}
+		}
+		err = ErrNotXML
+	default:
+		typ, _, _ := mime.ParseMediaType(http.DetectContentType(q))
+		r = SniffResult{MediaType: typ, Confidence: 0.5}
+		err = ErrNotXML
+	}
+	switch {
+	case r.MediaType == "" && hint.MediaType != "":
+		r = hint
+	case r.MediaType == "":
+		r = SniffResult{MediaType: "application/xml", Confidence: 0.3}
+	case r.MediaType == hint.MediaType && r.Confidence < 1:
+		// both of the content and the hint agree.
+		r.Confidence += 0.2
+		if r.Confidence > 1 {
+			r.Confidence = 1
+		}
+	}
+	return r, err
+}
+
+// sniffReader returns a reader that reads the same bytes as r.
+// It returns an error instead if the leading bytes of r are obviously not a feed.
+func sniffReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, SniffLen)
+	p, err := br.Peek(SniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch _, err := Sniff(p, "", ""); err {
+	case ErrEmpty, ErrNotXML, ErrHTMLNotFeed:
+		return nil, err
+	}
+	return br, nil
+}
+
+var extMediaTypes = map[string]string{
+	".rss":  "application/rss+xml",
+	".rdf":  "application/rdf+xml",
+	".atom": "application/atom+xml",
+	".xml":  "application/xml",
+	".json": "application/json",
+	".htm":  "text/html",
+	".html": "text/html",
+}
+
+// sniffHint returns a guess from Content-Type header or the extension of name.
+func sniffHint(contentType, name string) SniffResult {
+	if typ, _, err := mime.ParseMediaType(contentType); err == nil {
+		return SniffResult{MediaType: typ, Confidence: 0.5}
+	}
+	if u, err := url.Parse(name); err == nil {
+		name = u.Path
+	}
+	if typ, ok := extMediaTypes[strings.ToLower(path.Ext(name))]; ok {
+		return SniffResult{MediaType: typ, Confidence: 0.3}
+	}
+	return SniffResult{}
+}
+
+// decodeBOM removes a byte order mark from p. If p is encoded in UTF-16,
+// decodeBOM returns p converted to UTF-8.
+func decodeBOM(p []byte) []byte {
+	var order func(b []byte) uint16
+	switch {
+	case bytes.HasPrefix(p, []byte{0xEF, 0xBB, 0xBF}):
+		return p[3:]
+	case bytes.HasPrefix(p, []byte{0xFE, 0xFF}):
+		order = func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) }
+	case bytes.HasPrefix(p, []byte{0xFF, 0xFE}):
+		order = func(b []byte) uint16 { return uint16(b[1])<<8 | uint16(b[0]) }
+	default:
+		return p
+	}
+	p = p[2:]
+	s := make([]uint16, 0, len(p)/2)
+	for ; len(p) >= 2; p = p[2:] {
+		s = append(s, order(p))
+	}
+	return []byte(string(utf16.Decode(s)))
+}
+
+// bomReader returns a reader that reads r without a byte order mark.
+// If r starts with the byte order mark of UTF-16, the reader converts r to UTF-8.
+func bomReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	p, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(p, []byte{0xEF, 0xBB, 0xBF}):
+		br.Discard(3)
+	case bytes.HasPrefix(p, []byte{0xFE, 0xFF}):
+		br.Discard(2)
+		return &utf16Reader{r: br, order: binary.BigEndian}, nil
+	case bytes.HasPrefix(p, []byte{0xFF, 0xFE}):
+		br.Discard(2)
+		return &utf16Reader{r: br, order: binary.LittleEndian}, nil
+	}
+	return br, nil
+}
+
+// utf16Reader is an io.Reader that converts UTF-16 text read from r to UTF-8.
+// Unpaired surrogates are replaced with utf8.RuneError.
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	in    []byte // bytes not converted yet
+	out   []byte // converted bytes not returned yet
+	err   error
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if cap(r.in) == 0 {
+			r.in = make([]byte, 0, cleanReaderBufSize)
+		}
+		n, err := r.r.Read(r.in[len(r.in):cap(r.in)])
+		r.in = r.in[:len(r.in)+n]
+		r.err = err
+		var m int
+		r.out, m = r.appendUTF8(r.out[:0], r.in, err != nil)
+		r.in = r.in[:copy(r.in, r.in[m:])]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// appendUTF8 appends characters of p converted to UTF-8 to dst.
+// If atEOF is false, a incomplete character at the end of p is not consumed.
+// It returns the extended buffer and the number of bytes consumed.
+func (r *utf16Reader) appendUTF8(dst, p []byte, atEOF bool) ([]byte, int) {
+	i := 0
+	for len(p)-i >= 2 {
+		c := rune(r.order.Uint16(p[i:]))
+		if !utf16.IsSurrogate(c) {
+			dst = utf8.AppendRune(dst, c)
+			i += 2
+			continue
+		}
+		if len(p)-i < 4 {
+			if !atEOF {
+				return dst, i
+			}
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += 2
+			continue
+		}
+		if c2 := utf16.DecodeRune(c, rune(r.order.Uint16(p[i+2:]))); c2 != utf8.RuneError {
+			dst = utf8.AppendRune(dst, c2)
+			i += 4
+			continue
+		}
+		dst = utf8.AppendRune(dst, utf8.RuneError)
+		i += 2
+	}
+	if atEOF && i < len(p) {
+		dst = utf8.AppendRune(dst, utf8.RuneError)
+		i = len(p)
+	}
+	return dst, i
+}
+
+// charsetReader is CharsetReader of xml.Decoder. Documents are already
+// converted to UTF-8 by bomReader, so it accepts only labels of Unicode.
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-16", "utf-16be", "utf-16le":
+		return r, nil
+	}
+	return nil, fmt.Errorf("news: unsupported encoding %q", charset)
+}
+
+var htmlElements = map[string]bool{
+	"html": true,
+	"head": true,
+	"body": true,
+	"meta": true,
+	"link": true,
+	"div":  true,
+	"p":    true,
+}
+
+var rootMediaTypes = map[string]string{
+	"rss":  "application/rss+xml",
+	"rdf":  "application/rdf+xml",
+	"feed": "application/atom+xml",
+}
+
+// sniffMarkup guesses the media type of a document that starts with '<'.
+// It returns zero SniffResult if the leading bytes are not enough to guess.
+func sniffMarkup(q []byte) (SniffResult, error) {
+	doctype, tag := scanRootElement(q)
+	name := tagName(tag)
+	if strings.EqualFold(doctype, "html") || htmlElements[strings.ToLower(name)] {
+		return SniffResult{MediaType: "text/html", Confidence: 0.9}, ErrHTMLNotFeed
+	}
+	start, complete := decodeStartElement(tag)
+	if complete {
+		if d, err := detectDialect(start); err == nil {
+			return SniffResult{MediaType: d.MIMETypes()[0], Dialect: d, Confidence: 1}, nil
+		}
+	}
+
+	// guess from the name of the root element.
+	local := name
+	if i := strings.IndexByte(local, ':'); i >= 0 {
+		local = local[i+1:]
+	}
+	typ, ok := rootMediaTypes[strings.ToLower(local)]
+	switch {
+	case complete && ok:
+		// the root looks like a feed but it does not match to any dialects.
+		return SniffResult{MediaType: typ, Confidence: 0.6}, ErrUnknownDialect
+	case complete:
+		return SniffResult{MediaType: "application/xml", Confidence: 0.5}, ErrUnknownDialect
+	case ok:
+		return SniffResult{MediaType: typ, Confidence: 0.6}, nil
+	default:
+		return SniffResult{}, nil
+	}
+}
+
+// scanRootElement skips XML declaration, processing instructions, comments
+// and doctype in q, then returns the name of doctype and the start tag of
+// the root element. The start tag will be truncated if q ends in the tag.
+func scanRootElement(q []byte) (doctype string, tag []byte) {
+	for {
+		q = bytes.TrimLeft(q, " \t\r\n")
+		switch {
+		case len(q) == 0 || q[0] != '<':
+			return
+		case bytes.HasPrefix(q, []byte("<?")):
+			q = skipPast(q, "?>")
+		case bytes.HasPrefix(q, []byte("<!--")):
+			q = skipPast(q, "-->")
+		case len(q) >= 9 && strings.EqualFold(string(q[:9]), "<!doctype"):
+			f := strings.Fields(string(q[9:]))
+			if len(f) > 0 {
+				doctype = strings.TrimSuffix(f[0], ">")
+			}
+			q = skipDoctype(q)
+		default:
+			if i := bytes.IndexByte(q, '>'); i >= 0 {
+				return doctype, q[:i+1]
+			}
+			return doctype, q
+		}
+	}
+}
+
+func skipPast(q []byte, s string) []byte {
+	i := bytes.Index(q, []byte(s))
+	if i < 0 {
+		return nil
+	}
+	return q[i+len(s):]
+}
+
+// skipDoctype skips doctype declaration that might have internal subset.
+func skipDoctype(q []byte) []byte {
+	depth := 0
+	for i, c := range q {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '>':
+			if depth <= 0 {
+				return q[i+1:]
+			}
+		}
+	}
+	return nil
+}
+
+// tagName returns the name of the start tag.
+func tagName(tag []byte) string {
+	if len(tag) < 2 {
+		return ""
+	}
+	s := string(tag[1:])
+	if i := strings.IndexAny(s, " \t\r\n/>"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// decodeStartElement decodes a start tag that is complete.
+func decodeStartElement(tag []byte) (xml.StartElement, bool) {
+	if len(tag) == 0 || tag[len(tag)-1] != '>' {
+		return xml.StartElement{}, false
+	}
+	d := xml.NewDecoder(bytes.NewReader(tag))
+	d.Strict = false
+	d.CharsetReader = func(charset string, r io.Reader) (io.Reader, error) {
+		return r, nil
+	}
+	start, err := readRootElement(d)
+	if err != nil {
+		return xml.StartElement{}, false
+	}
+	return start, true
+}
@@ -0,0 +1,177 @@
+package news
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16LE(s string) []byte {
+	p := []byte{0xFF, 0xFE}
+	for _, c := range utf16.Encode([]rune(s)) {
+		p = append(p, byte(c), byte(c>>8))
+	}
+	return p
+}
+
+func TestSniff(t *testing.T) {
+	tab := []struct {
+		name        string
+		p           []byte
+		contentType string
+		file        string
+		mediaType   string
+		dialect     Dialect
+		err         error
+	}{
+		{
+			name:      "atom",
+			p:         []byte("\xEF\xBB\xBF<?xml version=\"1.0\"?>\n<!-- comment -->\n<feed xmlns=\"http://www.w3.org/2005/Atom\">"),
+			mediaType: "application/atom+xml",
+			dialect:   Atom,
+		},
+		{
+			name:      "rss1",
+			p:         []byte(`<?xml version="1.0" encoding="EUC-JP"?><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">`),
+			mediaType: "application/rdf+xml",
+			dialect:   RSS1,
+		},
+		{
+			name:      "utf-16",
+			p:         encodeUTF16LE(`<?xml version="1.0" encoding="UTF-16"?><rss version="2.0"><channel>`),
+			mediaType: "application/rss+xml",
+			dialect:   RSS2,
+		},
+		{
+			name:      "rss 0.91",
+			p:         []byte(`<!DOCTYPE rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN" "http://my.netscape.com/publish/formats/rss-0.91.dtd"><rss version="0.91">`),
+			mediaType: "application/rss+xml",
+			err:       ErrUnknownDialect,
+		},
+		{
+			name:      "truncated",
+			p:         []byte(`<?xml version="1.0"?><rss version="2.0" xmlns:dc="http://pur`),
+			mediaType: "application/rss+xml",
+		},
+		{
+			name:        "declaration only",
+			p:           []byte(`<?xml version="1.0"?>`),
+			contentType: "application/atom+xml; charset=utf-8",
+			mediaType:   "application/atom+xml",
+		},
+		{
+			name:      "html",
+			p:         []byte("<!DOCTYPE html>\n<html><head><title>404</title>"),
+			mediaType: "text/html",
+			err:       ErrHTMLNotFeed,
+		},
+		{
+			name:        "html without doctype",
+			p:           []byte("<HTML><BODY>Not Found"),
+			contentType: "application/rss+xml",
+			mediaType:   "text/html",
+			err:         ErrHTMLNotFeed,
+		},
+		{
+			name:      "json feed",
+			p:         []byte(`{"version": "https://jsonfeed.org/version/1.1", "title": "a"}`),
+			mediaType: "application/feed+json",
+			err:       ErrNotXML,
+		},
+		{
+			name:      "text",
+			p:         []byte("Service Unavailable"),
+			mediaType: "text/plain",
+			err:       ErrNotXML,
+		},
+		{
+			name:      "empty",
+			p:         []byte(" \r\n"),
+			file:      "http://example.com/index.rdf?x=1",
+			mediaType: "application/rdf+xml",
+			err:       ErrEmpty,
+		},
+	}
+	for _, v := range tab {
+		r, err := Sniff(v.p, v.contentType, v.file)
+		if err != v.err {
+			t.Errorf("%s: Sniff = %v; want %v", v.name, err, v.err)
+		}
+		if r.MediaType != v.mediaType {
+			t.Errorf("%s: MediaType = %q; want %q", v.name, r.MediaType, v.mediaType)
+		}
+		if r.Dialect != v.dialect {
+			t.Errorf("%s: Dialect = %v; want %v", v.name, r.Dialect, v.dialect)
+		}
+		if r.Confidence <= 0 || r.Confidence > 1 {
+			t.Errorf("%s: Confidence = %v", v.name, r.Confidence)
+		}
+	}
+}
+
+func TestSniffConfidence(t *testing.T) {
+	p := []byte(`<rss version="2.0"`)
+	r1, _ := Sniff(p, "", "")
+	r2, _ := Sniff(p, "", "feed.rss")
+	if r2.Confidence <= r1.Confidence {
+		t.Errorf("Confidence with the extension = %v; want > %v", r2.Confidence, r1.Confidence)
+	}
+}
+
+func TestParseNotFeed(t *testing.T) {
+	tab := []struct {
+		s   string
+		err error
+	}{
+		{"", ErrEmpty},
+		{"<!DOCTYPE html>\n<html><body>Not Found</body></html>", ErrHTMLNotFeed},
+		{`{"error": "not found"}`, ErrNotXML},
+	}
+	for _, v := range tab {
+		if _, err := Parse(strings.NewReader(v.s)); err != v.err {
+			t.Errorf("Parse(%q) = %v; want %v", v.s, err, v.err)
+		}
+		if _, _, err := ParseLenient(strings.NewReader(v.s)); err != v.err {
+			t.Errorf("ParseLenient(%q) = %v; want %v", v.s, err, v.err)
+		}
+	}
+}
+
+func encodeUTF16BE(s string) []byte {
+	p := []byte{0xFE, 0xFF}
+	for _, c := range utf16.Encode([]rune(s)) {
+		p = append(p, byte(c>>8), byte(c))
+	}
+	return p
+}
+
+func TestParseUTF16(t *testing.T) {
+	s := `<?xml version="1.0" encoding="UTF-16"?>
+<rss version="2.0"><channel><title>日本語 😀</title>
+<item><title>a</title><description>` + strings.Repeat("0123456789", 1000) + `</description></item>
+</channel></rss>`
+	tab := []struct {
+		name string
+		p    []byte
+	}{
+		{"LE", encodeUTF16LE(s)},
+		{"BE", encodeUTF16BE(s)},
+	}
+	for _, v := range tab {
+		feed, err := Parse(bytes.NewReader(v.p))
+		if err != nil {
+			t.Errorf("%s: Parse = %v", v.name, err)
+			continue
+		}
+		if want := "日本語 \U0001F600"; feed.Title != want {
+			t.Errorf("%s: Title = %q; want %q", v.name, feed.Title, want)
+		}
+		if len(feed.Articles) != 1 || len(feed.Articles[0].Content) != 10000 {
+			t.Errorf("%s: Articles = %v", v.name, feed.Articles)
+		}
+		if _, _, err := ParseLenient(bytes.NewReader(v.p)); err != nil {
+			t.Errorf("%s: ParseLenient = %v", v.name, err)
+		}
+	}
+}
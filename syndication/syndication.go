@@ -0,0 +1,89 @@
+// Package syndication implements the RSS 1.0 Syndication module.
+package syndication
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/lufia/news/extension"
+)
+
+// Namespace is the namespace of the Syndication module.
+const Namespace = "http://purl.org/rss/1.0/modules/syndication/"
+
+// Update is the set of sy:updatePeriod, sy:updateFrequency and sy:updateBase.
+type Update struct {
+	Period    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod,omitempty"`
+	Frequency int    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency,omitempty"`
+	Base      Date   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateBase,omitempty"`
+}
+
+// Date is a date of W3C Date and Time Formats (W3CDTF), such as
+// 2000-01-01T12:00+00:00. It is zero if the value can't be parsed.
+type Date time.Time
+
+// dateLayouts are layouts of W3CDTF.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func (date Date) String() string {
+	return time.Time(date).String()
+}
+
+// UnmarshalXML decodes the date leniently; an invalid value is ignored
+// because sy:updateBase is only a hint.
+func (date *Date) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	s = strings.TrimSpace(s)
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			*date = Date(t)
+			return nil
+		}
+	}
+	*date = Date{}
+	return nil
+}
+
+var periods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// Interval returns the duration between updates.
+// Period defaults to daily, and Frequency defaults to 1.
+func (u *Update) Interval() time.Duration {
+	d, ok := periods[strings.TrimSpace(u.Period)]
+	if !ok {
+		d = periods["daily"]
+	}
+	if u.Frequency > 0 {
+		d /= time.Duration(u.Frequency)
+	}
+	return d
+}
+
+func init() {
+	extension.Register(Namespace, decode)
+}
+
+// decode decodes the Syndication elements to *Update.
+func decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var u Update
+	if err := d.DecodeElement(&u, &start); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,49 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestUpdateInterval(t *testing.T) {
+	tab := []struct {
+		Update Update
+		Want   time.Duration
+	}{
+		{Update: Update{}, Want: 24 * time.Hour},
+		{Update: Update{Period: "hourly"}, Want: time.Hour},
+		{Update: Update{Period: "hourly", Frequency: 2}, Want: 30 * time.Minute},
+		{Update: Update{Period: "weekly", Frequency: 7}, Want: 24 * time.Hour},
+		{Update: Update{Period: "unknown"}, Want: 24 * time.Hour},
+	}
+	for _, v := range tab {
+		if d := v.Update.Interval(); d != v.Want {
+			t.Errorf("(%#v).Interval() = %v; want %v", v.Update, d, v.Want)
+		}
+	}
+}
+
+func TestDecodeUpdateBase(t *testing.T) {
+	tab := []struct {
+		S    string
+		Want time.Time
+	}{
+		{S: "2000-01-01T12:00+00:00", Want: time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{S: "2000-01-01T12:00:30+09:00", Want: time.Date(2000, 1, 1, 3, 0, 30, 0, time.UTC)},
+		{S: " 2000-01-01 ", Want: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{S: "2000", Want: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{S: "yesterday"},
+	}
+	for _, v := range tab {
+		s := `<channel xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><sy:updateBase>` + v.S + `</sy:updateBase></channel>`
+		var u Update
+		if err := xml.Unmarshal([]byte(s), &u); err != nil {
+			t.Errorf("Unmarshal(%q) = %v", s, err)
+			continue
+		}
+		if base := time.Time(u.Base); !base.Equal(v.Want) {
+			t.Errorf("Unmarshal(%q): Base = %v; want %v", s, base, v.Want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package thread implements the Atom Threading Extensions (RFC 4685).
+package thread
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/extension"
+)
+
+// Namespace is the namespace of the Atom Threading Extensions.
+const Namespace = "http://purl.org/syndication/thread/1.0"
+
+// Thread is the set of thr:in-reply-to and thr:total.
+type Thread struct {
+	InReplyTo []InReplyTo `xml:"http://purl.org/syndication/thread/1.0 in-reply-to,omitempty"`
+	Total     int         `xml:"http://purl.org/syndication/thread/1.0 total,omitempty"`
+}
+
+// InReplyTo is a reference to the resource that an entry responds to.
+type InReplyTo struct {
+	Ref    string `xml:"ref,attr"`
+	URL    string `xml:"href,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Source string `xml:"source,attr,omitempty"`
+}
+
+func init() {
+	extension.Register(Namespace, decode)
+}
+
+// decode decodes the Threading elements to *Thread.
+func decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var t Thread
+	if err := d.DecodeElement(&t, &start); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
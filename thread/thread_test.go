@@ -0,0 +1,35 @@
+package thread
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/lufia/news/extension"
+)
+
+func TestDecode(t *testing.T) {
+	s := `<entry xmlns:thr="http://purl.org/syndication/thread/1.0">
+		<thr:in-reply-to ref="tag:example.org,1999:1" href="http://example.org/1" type="text/html"/>
+		<thr:total>10</thr:total>
+	</entry>`
+	var v struct {
+		Extensions []extension.Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := extension.New(v.Extensions).Decode(Namespace)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", Namespace, err)
+	}
+	want := &Thread{
+		InReplyTo: []InReplyTo{
+			{Ref: "tag:example.org,1999:1", URL: "http://example.org/1", Type: "text/html"},
+		},
+		Total: 10,
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Decode(%q) = %#v; want %#v", Namespace, p, want)
+	}
+}
@@ -0,0 +1,225 @@
+package validate
+
+import (
+	"encoding/xml"
+	"net/mail"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/thread"
+)
+
+// atomDialect is rules of Atom (RFC 4287).
+// If legacy is true, the document is Atom 0.3; only common rules are applied.
+type atomDialect struct {
+	legacy bool
+}
+
+func (atomDialect) valueKind(elem, attr xml.Name) valueKind {
+	if attr.Local != "" {
+		switch {
+		case attr.Space == thread.Namespace && attr.Local == "count":
+			return integer
+		case attr.Space == thread.Namespace && attr.Local == "updated":
+			return rfc3339Date
+		case attr.Space != "":
+			return noValue
+		case elem.Local == "link" && attr.Local == "length":
+			return integer
+		case elem.Space == atom.TombstonesNamespace && elem.Local == "deleted-entry" && attr.Local == "when":
+			return rfc3339Date
+		}
+		return noValue
+	}
+	switch elem.Space {
+	case atom.Namespace, atom.Namespace03:
+		switch elem.Local {
+		case "updated", "published", "modified", "issued", "created":
+			return rfc3339Date
+		}
+	}
+	return extensionValueKind(elem)
+}
+
+// atomTextTypes are types of Text constructs.
+var atomTextTypes = map[string]bool{
+	"":      true,
+	"text":  true,
+	"html":  true,
+	"xhtml": true,
+}
+
+func (x atomDialect) validate(v *validator, d *xml.Decoder, start *xml.StartElement) error {
+	feed, err := atom.DecodeElement(d, start)
+	if err != nil {
+		return err
+	}
+	root := "feed"
+	if !x.legacy {
+		v.checkAtomID(root, feed.ID)
+		v.checkPresent(root, "title")
+		v.checkPresent(root, "updated")
+		self := false
+		for _, link := range feed.Links {
+			if link.Rel == "self" {
+				self = true
+			}
+		}
+		if !self {
+			v.add(Warning, root, "feed should have <link rel=\"self\"> that is the URL of the feed")
+		}
+	}
+	v.checkAtomText(root+"/title", feed.Title, false)
+	v.checkAtomText(root+"/subtitle", feed.Subtitle, false)
+	v.checkAtomText(root+"/rights", feed.Rights, false)
+	v.checkAtomLinkList(root, feed.Links)
+	for i, p := range feed.Authors {
+		v.checkAtomPerson(root+"/"+elementName("author", i), p)
+	}
+	for i, c := range feed.Categories {
+		v.checkAtomCategory(root+"/"+elementName("category", i), c)
+	}
+	if g := feed.Generator; g != nil && g.URL != "" {
+		v.checkURLRef(Error, root+"/generator@uri", g.URL)
+	}
+	if feed.Icon != "" {
+		v.checkURLRef(Error, root+"/icon", feed.Icon)
+	}
+	if feed.Logo != "" {
+		v.checkURLRef(Error, root+"/logo", feed.Logo)
+	}
+
+	var (
+		ids   []string
+		paths []string
+	)
+	for i, entry := range feed.Entries {
+		path := root + "/" + elementName("entry", i)
+		x.validateEntry(v, path, entry, len(feed.Authors) > 0)
+		ids = append(ids, entry.ID)
+		paths = append(paths, path+"/id")
+	}
+	v.checkUnique("id", ids, paths)
+	for i, entry := range feed.DeletedEntries {
+		path := root + "/" + elementName("deleted-entry", i)
+		if entry.Ref == "" {
+			v.add(Error, path+"@ref", "missing ref attribute")
+		}
+	}
+	return nil
+}
+
+func (x atomDialect) validateEntry(v *validator, path string, entry *atom.Entry, feedAuthors bool) {
+	if !x.legacy {
+		v.checkAtomID(path, entry.ID)
+		v.checkPresent(path, "title")
+		v.checkPresent(path, "updated")
+		if len(entry.Authors) == 0 && !feedAuthors {
+			v.add(Error, path, "entry must have <author> unless the feed has <author>")
+		}
+		if entry.Content.IsZero() && entry.AlternateURL() == "" {
+			v.add(Error, path, "entry must have <content> or <link rel=\"alternate\">")
+		}
+	}
+	v.checkAtomText(path+"/title", entry.Title, false)
+	v.checkAtomText(path+"/summary", entry.Summary, false)
+	v.checkAtomText(path+"/rights", entry.Rights, false)
+	v.checkAtomText(path+"/content", entry.Content, true)
+	v.checkAtomLinkList(path, entry.Links)
+	for i, p := range entry.Authors {
+		v.checkAtomPerson(path+"/"+elementName("author", i), p)
+	}
+	for i, c := range entry.Categories {
+		v.checkAtomCategory(path+"/"+elementName("category", i), c)
+	}
+}
+
+// checkPresent checks that the parent element has the child element of name.
+func (v *validator) checkPresent(parent, name string) {
+	if !v.has(parent + "/" + name) {
+		v.add(Error, parent+"/"+name, "missing <%s>", name)
+	}
+}
+
+// checkAtomID checks that the parent element has <id> that is an absolute IRI.
+func (v *validator) checkAtomID(parent, id string) {
+	path := parent + "/id"
+	switch {
+	case !v.has(path):
+		v.add(Error, path, "missing <id>")
+	case id == "":
+		v.add(Error, path, "<id> is empty")
+	default:
+		v.checkURL(Error, path, id)
+	}
+}
+
+// checkAtomText checks the type of the Text construct.
+// If content is true, the type can be a MIME media type.
+func (v *validator) checkAtomText(path string, t atom.Text, content bool) {
+	switch {
+	case atomTextTypes[t.Type]:
+	case content:
+		v.checkMediaType(Error, path+"@type", t.Type)
+	default:
+		v.add(Error, path+"@type", "type %q is not one of text, html or xhtml", t.Type)
+	}
+}
+
+// checkAtomLinkList checks links of the parent element. At most one
+// alternate link is allowed for each combination of type and hreflang.
+func (v *validator) checkAtomLinkList(parent string, links []atom.Link) {
+	type key struct {
+		typ      string
+		hreflang string
+	}
+	alternates := make(map[key]bool)
+	for i, link := range links {
+		path := parent + "/" + elementName("link", i)
+		v.checkAtomLink(path, link)
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		k := key{link.Type, link.HrefLang}
+		if alternates[k] {
+			v.add(Error, path, "duplicate alternate link of type %q and hreflang %q", link.Type, link.HrefLang)
+		}
+		alternates[k] = true
+	}
+}
+
+// checkAtomLink checks attributes of the link at path.
+func (v *validator) checkAtomLink(path string, link atom.Link) {
+	if link.URL == "" {
+		v.add(Error, path+"@href", "missing href attribute")
+	} else {
+		v.checkURLRef(Error, path+"@href", link.URL)
+	}
+	if link.Type != "" {
+		v.checkMediaType(Error, path+"@type", link.Type)
+	}
+	if link.HrefLang != "" && !isLanguageTag(link.HrefLang) {
+		v.add(Warning, path+"@hreflang", "%q is not a language tag", link.HrefLang)
+	}
+}
+
+func (v *validator) checkAtomPerson(path string, p atom.Person) {
+	v.checkRequired(path, "name", p.Name)
+	if p.URL != "" {
+		v.checkURLRef(Warning, path+"/uri", p.URL)
+	}
+	if p.Email != "" {
+		// Atom requires an addr-spec without names.
+		if a, err := mail.ParseAddress(p.Email); err != nil || a.Name != "" || a.Address != p.Email {
+			v.add(Warning, path+"/email", "%q is not an email address", p.Email)
+		}
+	}
+}
+
+func (v *validator) checkAtomCategory(path string, c atom.Category) {
+	if c.Term == "" {
+		v.add(Error, path+"@term", "missing term attribute")
+	}
+	if c.Scheme != "" {
+		v.checkURLRef(Error, path+"@scheme", c.Scheme)
+	}
+}
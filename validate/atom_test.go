@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"testing"
+)
+
+func TestValidateAtom(t *testing.T) {
+	testValidate(t, []validateTest{
+		{
+			Name: "valid",
+			XMLString: `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+	<id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+	<title type="text">Example</title>
+	<updated>2003-12-13T18:30:02Z</updated>
+	<author><name>John Doe</name><email>john@example.com</email></author>
+	<link rel="self" type="application/atom+xml" href="http://example.org/feed.atom"/>
+	<link href="http://example.org/"/>
+	<entry>
+		<id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+		<title>Entry</title>
+		<updated>2003-12-13T18:30:02Z</updated>
+		<link href="http://example.org/2003/12/13/atom03"/>
+		<link rel="alternate" hreflang="ja" href="http://example.org/ja/2003/12/13/atom03"/>
+		<content type="text/plain">content</content>
+	</entry>
+</feed>`,
+			Want: []string{},
+		},
+		{
+			Name: "invalid",
+			XMLString: `<feed xmlns="http://www.w3.org/2005/Atom">
+	<title type="plain">Example</title>
+	<link href="http://example.org/" type="html"/>
+	<entry>
+		<id>urn:a</id>
+		<updated>2003-12-13</updated>
+		<link href="http://example.org/1"/>
+		<link href="http://example.org/2"/>
+		<author><name>John</name><email>John &lt;john@example.com&gt;</email></author>
+	</entry>
+	<entry>
+		<id>urn:a</id>
+		<title>Entry</title>
+		<updated>2003-12-13T18:30:02Z</updated>
+		<content>content</content>
+		<category scheme="http://example.org/"/>
+	</entry>
+</feed>`,
+			Want: []string{
+				"1:1: error: feed/id",
+				"1:1: error: feed/updated",
+				"1:1: warning: feed",
+				"2:2: error: feed/title@type",
+				"3:2: error: feed/link@type",
+				"4:2: error: feed/entry/title",
+				"6:3: error: feed/entry/updated",
+				"8:3: error: feed/entry/link[2]",
+				"9:28: warning: feed/entry/author/email",
+				"11:2: error: feed/entry[2]",
+				"12:3: error: feed/entry[2]/id",
+				"16:3: error: feed/entry[2]/category@term",
+			},
+		},
+		{
+			Name:      "atom 0.3",
+			XMLString: `<feed xmlns="http://purl.org/atom/ns#" version="0.3"><title>a</title><modified>2003-12-13T18:30:02Z</modified></feed>`,
+			Want:      []string{"1:1: warning: feed"},
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/rss1"
+)
+
+// rss1Dialect is rules of RSS 1.0.
+// See https://web.resource.org/rss/1.0/spec for details.
+type rss1Dialect struct{}
+
+func (rss1Dialect) valueKind(elem, attr xml.Name) valueKind {
+	if attr.Local != "" {
+		return noValue
+	}
+	return extensionValueKind(elem)
+}
+
+func (rss1Dialect) validate(v *validator, d *xml.Decoder, start *xml.StartElement) error {
+	feed, err := rss1.DecodeElement(d, start)
+	if err != nil {
+		return err
+	}
+	root := "RDF"
+	c := feed.Channel
+	if c == nil {
+		v.add(Error, root+"/channel", "missing <channel>")
+		return nil
+	}
+	path := root + "/channel"
+	if c.About == "" {
+		v.add(Error, path+"@about", "missing rdf:about attribute")
+	} else {
+		v.checkURL(Error, path+"@about", c.About)
+	}
+	v.checkRequired(path, "title", c.Title)
+	v.checkRequired(path, "link", c.Link)
+	v.checkRequired(path, "description", c.Description)
+	if c.Link != "" {
+		v.checkURL(Error, path+"/link", c.Link)
+	}
+	if img := feed.Image; img != nil {
+		p := root + "/image"
+		if img.About == "" {
+			v.add(Error, p+"@about", "missing rdf:about attribute")
+		}
+		v.checkRequired(p, "title", img.Title)
+		v.checkRequired(p, "url", img.URL)
+		v.checkRequired(p, "link", img.Link)
+		if img.URL != "" {
+			v.checkURL(Error, p+"/url", img.URL)
+		}
+		if img.Link != "" {
+			v.checkURL(Error, p+"/link", img.Link)
+		}
+	}
+
+	indexed := make(map[string]bool)
+	for _, index := range c.Indexes {
+		indexed[index.URL] = true
+	}
+	if len(feed.Items) > 0 && len(c.Indexes) == 0 {
+		v.add(Error, path+"/items", "missing <items> that lists items in rdf:Seq")
+	}
+	var (
+		ids   []string
+		paths []string
+	)
+	for i, item := range feed.Items {
+		p := root + "/" + elementName("item", i)
+		v.checkRequired(p, "title", item.Title)
+		v.checkRequired(p, "link", item.Link)
+		if item.Link != "" {
+			v.checkURL(Error, p+"/link", item.Link)
+		}
+		switch {
+		case item.About == "":
+			v.add(Error, p+"@about", "missing rdf:about attribute")
+		case len(c.Indexes) > 0 && !indexed[item.About]:
+			v.add(Warning, p+"@about", "item %q is not listed in <items> of the channel", item.About)
+		}
+		ids = append(ids, item.About)
+		paths = append(paths, p)
+	}
+	v.checkUnique("rdf:about", ids, paths)
+	return nil
+}
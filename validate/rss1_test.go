@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"testing"
+)
+
+func TestValidateRSS1(t *testing.T) {
+	testValidate(t, []validateTest{
+		{
+			Name: "valid",
+			XMLString: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="http://example.com/feed">
+		<title>Example</title>
+		<link>http://example.com/</link>
+		<description>Example channel</description>
+		<dc:date>2008-06-10T04:00:00Z</dc:date>
+		<items>
+			<rdf:Seq>
+				<rdf:li rdf:resource="http://example.com/1"/>
+			</rdf:Seq>
+		</items>
+	</channel>
+	<item rdf:about="http://example.com/1">
+		<title>1</title>
+		<link>http://example.com/1</link>
+	</item>
+</rdf:RDF>`,
+			Want: []string{},
+		},
+		{
+			Name: "invalid",
+			XMLString: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+	<channel>
+		<title>Example</title>
+		<link>example.com</link>
+		<description/>
+		<items>
+			<rdf:Seq>
+				<rdf:li rdf:resource="http://example.com/1"/>
+			</rdf:Seq>
+		</items>
+	</channel>
+	<item rdf:about="http://example.com/1">
+		<title>1</title>
+		<link>http://example.com/1</link>
+	</item>
+	<item rdf:about="http://example.com/1">
+		<title>2</title>
+	</item>
+</rdf:RDF>`,
+			Want: []string{
+				"2:2: error: RDF/channel@about",
+				"4:3: error: RDF/channel/link",
+				"5:3: error: RDF/channel/description",
+				"16:2: error: RDF/item[2]/link",
+				"16:2: error: RDF/item[2]",
+			},
+		},
+	})
+}
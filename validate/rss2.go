@@ -0,0 +1,235 @@
+package validate
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strings"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/dublincore"
+	"github.com/lufia/news/rss2"
+	"github.com/lufia/news/syndication"
+)
+
+// rss2Dialect is rules of RSS 2.0.
+// See https://www.rssboard.org/rss-specification for details.
+type rss2Dialect struct{}
+
+func (rss2Dialect) valueKind(elem, attr xml.Name) valueKind {
+	if attr.Local != "" {
+		switch {
+		case attr.Space != "":
+			return noValue
+		case elem.Local == "enclosure" && attr.Local == "length":
+			return integer
+		case elem.Local == "cloud" && attr.Local == "port":
+			return integer
+		}
+		return noValue
+	}
+	switch elem.Space {
+	case "":
+		switch elem.Local {
+		case "pubDate", "lastBuildDate":
+			return rfc822Date
+		case "ttl", "width", "height", "hour":
+			return integer
+		}
+	case atom.Namespace:
+		if elem.Local == "updated" || elem.Local == "published" {
+			return rfc3339Date
+		}
+	}
+	return extensionValueKind(elem)
+}
+
+// extensionValueKind returns the kind of the value of elem in extension modules.
+func extensionValueKind(elem xml.Name) valueKind {
+	switch {
+	case elem.Space == dublincore.Namespace && elem.Local == "date":
+		return w3cDate
+	case elem.Space == dublincore.TermsNamespace && elem.Local == "modified":
+		return w3cDate
+	case elem.Space == syndication.Namespace && elem.Local == "updateBase":
+		return w3cDate
+	}
+	return noValue
+}
+
+var rss2Days = map[string]bool{
+	"Monday":    true,
+	"Tuesday":   true,
+	"Wednesday": true,
+	"Thursday":  true,
+	"Friday":    true,
+	"Saturday":  true,
+	"Sunday":    true,
+}
+
+var rss2CloudProtocols = map[string]bool{
+	"xml-rpc":   true,
+	"soap":      true,
+	"http-post": true,
+}
+
+func (rss2Dialect) validate(v *validator, d *xml.Decoder, start *xml.StartElement) error {
+	feed, err := rss2.DecodeElement(d, start)
+	if err != nil {
+		return err
+	}
+	root := "rss"
+	if feed.Version != "2.0" {
+		v.add(Warning, root, "version %q is not 2.0", feed.Version)
+	}
+	c := feed.Channel
+	if c == nil {
+		v.add(Error, root+"/channel", "missing <channel>")
+		return nil
+	}
+	path := root + "/channel"
+	v.checkRequired(path, "title", c.Title)
+	v.checkRequired(path, "link", c.Link)
+	if !v.has(path + "/description") {
+		v.add(Error, path+"/description", "missing <description>")
+	}
+	if c.Link != "" {
+		v.checkURL(Error, path+"/link", c.Link)
+	}
+	if c.Language != "" && !isLanguageTag(c.Language) {
+		v.add(Warning, path+"/language", "%q is not a language tag", c.Language)
+	}
+	if c.ManagingEditor != "" {
+		v.checkEmail(Warning, path+"/managingEditor", c.ManagingEditor)
+	}
+	if c.WebMaster != "" {
+		v.checkEmail(Warning, path+"/webMaster", c.WebMaster)
+	}
+	if c.Docs != "" {
+		v.checkURL(Warning, path+"/docs", c.Docs)
+	}
+	if cloud := c.Cloud; cloud != nil && !rss2CloudProtocols[cloud.Protocol] {
+		v.add(Error, path+"/cloud@protocol", "protocol %q is not one of xml-rpc, soap or http-post", cloud.Protocol)
+	}
+	if img := c.Image; img != nil {
+		p := path + "/image"
+		v.checkRequired(p, "url", img.URL)
+		v.checkRequired(p, "title", img.Title)
+		v.checkRequired(p, "link", img.Link)
+		if img.URL != "" {
+			v.checkURL(Error, p+"/url", img.URL)
+		}
+		if img.Link != "" {
+			v.checkURL(Error, p+"/link", img.Link)
+		}
+		if img.Width > 144 {
+			v.add(Error, p+"/width", "width %d exceeds 144", img.Width)
+		}
+		if img.Height > 400 {
+			v.add(Error, p+"/height", "height %d exceeds 400", img.Height)
+		}
+	}
+	if ti := c.TextInput; ti != nil {
+		p := path + "/textInput"
+		v.checkRequired(p, "title", ti.Title)
+		v.checkRequired(p, "description", ti.Description)
+		v.checkRequired(p, "name", ti.Name)
+		v.checkRequired(p, "link", ti.Link)
+		if ti.Link != "" {
+			v.checkURL(Error, p+"/link", ti.Link)
+		}
+	}
+	for i, hour := range c.SkipHours {
+		if hour < 0 || hour > 23 {
+			v.add(Error, path+"/skipHours/"+elementName("hour", i), "hour %d is not between 0 and 23", hour)
+		}
+	}
+	for i, day := range c.SkipDays {
+		if !rss2Days[day] {
+			v.add(Error, path+"/skipDays/"+elementName("day", i), "%q is not a day of week", day)
+		}
+	}
+	v.checkAtomLinks(path, c.AtomLinks)
+
+	var (
+		ids   []string
+		paths []string
+	)
+	for i, item := range c.Items {
+		p := path + "/" + elementName("item", i)
+		v.validateRSS2Item(p, item)
+		ids = append(ids, item.Guid.Content)
+		paths = append(paths, p+"/guid")
+	}
+	v.checkUnique("guid", ids, paths)
+	return nil
+}
+
+func (v *validator) validateRSS2Item(path string, item *rss2.Item) {
+	if item.Title == "" && item.Description == "" {
+		v.add(Error, path, "item must have either <title> or <description>")
+	}
+	if item.Link != "" {
+		v.checkURL(Error, path+"/link", item.Link)
+	}
+	if item.Comments != "" {
+		v.checkURL(Error, path+"/comments", item.Comments)
+	}
+	if item.Author != "" {
+		v.checkEmail(Warning, path+"/author", item.Author)
+	}
+	switch guid := item.Guid; {
+	case guid.Content == "":
+		v.add(Info, path, "item should have <guid> to be identified uniquely")
+	case guid.IsPermaLink:
+		if u, err := url.Parse(guid.Content); err != nil || !u.IsAbs() {
+			v.add(Warning, path+"/guid", "guid %q is not a URL; set isPermaLink=\"false\" if it is not a permalink", guid.Content)
+		}
+	}
+	if e := item.Enclosure; e != nil {
+		p := path + "/enclosure"
+		if e.URL == "" {
+			v.add(Error, p+"@url", "missing url attribute")
+		} else {
+			v.checkURL(Error, p+"@url", e.URL)
+		}
+		if e.Type == "" {
+			v.add(Error, p+"@type", "missing type attribute")
+		} else {
+			v.checkMediaType(Error, p+"@type", e.Type)
+		}
+	}
+	if s := item.Source; s != nil {
+		if s.URL == "" {
+			v.add(Error, path+"/source@url", "missing url attribute")
+		} else {
+			v.checkURL(Error, path+"/source@url", s.URL)
+		}
+	}
+}
+
+// checkRequired checks that the parent element has the child element of name,
+// and its value is not empty.
+func (v *validator) checkRequired(parent, name, value string) {
+	path := parent + "/" + name
+	switch {
+	case !v.has(path):
+		v.add(Error, path, "missing <%s>", name)
+	case strings.TrimSpace(value) == "":
+		v.add(Error, path, "<%s> is empty", name)
+	}
+}
+
+// checkAtomLinks checks atom:link elements that are embedded in RSS.
+// They are reported at the channel because <link> of RSS has the same path.
+func (v *validator) checkAtomLinks(path string, links []atom.Link) {
+	self := false
+	for _, link := range links {
+		if link.Rel == "self" {
+			self = true
+		}
+		v.checkAtomLink(path+"/atom:link", link)
+	}
+	if !self {
+		v.add(Info, path, "channel should have atom:link rel=\"self\" that is the URL of the feed")
+	}
+}
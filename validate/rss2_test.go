@@ -0,0 +1,98 @@
+package validate
+
+import (
+	"testing"
+)
+
+func TestValidateRSS2(t *testing.T) {
+	testValidate(t, []validateTest{
+		{
+			Name: "valid",
+			XMLString: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+	<channel>
+		<title>Example</title>
+		<link>http://example.com/</link>
+		<description>Example channel</description>
+		<language>en-us</language>
+		<managingEditor>editor@example.com (Editor)</managingEditor>
+		<pubDate>Tue, 10 Jun 2008 04:00:00 GMT</pubDate>
+		<atom:link rel="self" type="application/rss+xml" href="http://example.com/feed"/>
+		<item>
+			<title>1</title>
+			<link>http://example.com/1</link>
+			<guid>http://example.com/1</guid>
+			<pubDate>Tue, 10 Jun 2008 04:00:00 +0900</pubDate>
+			<enclosure url="http://example.com/1.mp3" length="1337" type="audio/mpeg"/>
+		</item>
+	</channel>
+</rss>`,
+			Want: []string{},
+		},
+		{
+			Name: "invalid",
+			XMLString: `<rss version="0.92" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel>
+		<title>Example</title>
+		<link>/index.html</link>
+		<language>en us</language>
+		<webMaster>webmaster</webMaster>
+		<pubDate>Tue, 10 Jun 2008 04:00:00 JST</pubDate>
+		<lastBuildDate>yesterday</lastBuildDate>
+		<ttl>sixty</ttl>
+		<item>
+			<guid>1</guid>
+			<enclosure url="http://example.com/1.mp3" length="unknown" type="audio"/>
+			<dc:date>2008/06/10</dc:date>
+		</item>
+		<item>
+			<title>2</title>
+			<guid isPermaLink="false">1</guid>
+		</item>
+	</channel>
+</rss>`,
+			Want: []string{
+				"1:1: warning: rss",
+				"2:2: error: rss/channel/description",
+				"2:2: info: rss/channel",
+				"4:3: error: rss/channel/link",
+				"5:3: warning: rss/channel/language",
+				"6:3: warning: rss/channel/webMaster",
+				"7:3: warning: rss/channel/pubDate",
+				"8:3: error: rss/channel/lastBuildDate",
+				"9:3: error: rss/channel/ttl",
+				"10:3: error: rss/channel/item",
+				"11:4: warning: rss/channel/item/guid",
+				"12:4: error: rss/channel/item/enclosure@length",
+				"12:4: error: rss/channel/item/enclosure@type",
+				"13:4: error: rss/channel/item/date",
+				"17:4: error: rss/channel/item[2]/guid",
+			},
+		},
+		{
+			Name: "RFC 822 dates",
+			XMLString: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+	<channel>
+		<title>Example</title>
+		<link>http://example.com/</link>
+		<description>Example channel</description>
+		<pubDate>10 Jun 2008 04:00:00 GMT</pubDate>
+		<lastBuildDate>Tue, 10 Jun 08 04:00:00 +0900</lastBuildDate>
+		<atom:link rel="self" type="application/rss+xml" href="http://example.com/feed"/>
+		<item>
+			<title>1</title>
+			<guid>http://example.com/1</guid>
+			<pubDate>10 Jun 2008 04:00 +0900</pubDate>
+		</item>
+	</channel>
+</rss>`,
+			Want: []string{
+				"7:3: warning: rss/channel/lastBuildDate",
+			},
+		},
+		{
+			Name:      "no channel",
+			XMLString: `<rss version="2.0"></rss>`,
+			Want:      []string{"1:1: error: rss/channel"},
+		},
+	})
+}
@@ -0,0 +1,390 @@
+// Package validate checks feeds against the specifications of
+// RSS 1.0, RSS 2.0 and Atom (RFC 4287).
+package validate
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lufia/news/atom"
+	"github.com/lufia/news/rss1"
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+const (
+	// Info is a finding that is a recommendation.
+	Info Severity = iota
+
+	// Warning is a finding that readers might not handle well.
+	Warning
+
+	// Error is a violation of the specification.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Finding is a problem that is found in a document.
+type Finding struct {
+	Severity Severity
+	Path     string // path of the element, such as rss/channel/item[3]/pubDate
+	Line     int    // 1-based line number
+	Column   int    // 1-based column number
+	Message  string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%d:%d: %v: %s: %s", f.Line, f.Column, f.Severity, f.Path, f.Message)
+}
+
+// Validate reads a feed from r, and checks it against the specification of
+// its dialect. It returns findings that are sorted by their positions.
+//
+// Documents that are not well-formed are reported as findings;
+// the error is not nil only if reading r fails.
+func Validate(r io.Reader) ([]*Finding, error) {
+	v := &validator{pos: make(map[string]position)}
+	start, err := v.scan(r)
+	if err != nil {
+		return nil, err
+	}
+	if start != nil {
+		v.validate(start)
+	}
+	sort.SliceStable(v.findings, func(i, j int) bool {
+		a, b := v.findings[i], v.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.findings, nil
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+// element is an element that is open while scanning a document.
+type element struct {
+	path   string
+	counts map[string]int // number of children by name
+}
+
+// validator holds the state of validation of a document.
+type validator struct {
+	dialect  dialect
+	pos      map[string]position // positions of elements by path
+	tokens   []xml.Token         // tokens that are passed to decoders of dialects
+	findings []*Finding
+}
+
+// add adds a finding at the element of path, such as rss/channel/title or
+// rss/channel/item/enclosure@length. If there is no such element,
+// the finding is reported at the nearest ancestor.
+func (v *validator) add(sev Severity, path, format string, args ...interface{}) {
+	f := &Finding{
+		Severity: sev,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	p := path
+	if i := strings.IndexByte(p, '@'); i >= 0 {
+		p = p[:i] // path of an attribute
+	}
+	for p != "" {
+		if pos, ok := v.pos[p]; ok {
+			f.Line, f.Column = pos.line, pos.column
+			break
+		}
+		i := strings.LastIndexByte(p, '/')
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	v.findings = append(v.findings, f)
+}
+
+// has reports whether the document has the element of path.
+func (v *validator) has(path string) bool {
+	_, ok := v.pos[path]
+	return ok
+}
+
+// dialect is a set of rules of a dialect.
+type dialect interface {
+	// valueKind returns the kind of the value of the element or the attribute.
+	valueKind(elem, attr xml.Name) valueKind
+
+	// validate validates the document that starts with start;
+	// d reads tokens after start.
+	validate(v *validator, d *xml.Decoder, start *xml.StartElement) error
+}
+
+// detect returns the dialect of the document of which root element is start.
+func (v *validator) detect(start xml.StartElement, path string) dialect {
+	switch {
+	case start.Name.Space == rss1.RDFNamespace && start.Name.Local == "RDF":
+		return rss1Dialect{}
+	case start.Name.Space == "" && start.Name.Local == "rss":
+		return rss2Dialect{}
+	case start.Name.Space == atom.Namespace && start.Name.Local == "feed":
+		return atomDialect{}
+	case start.Name.Space == atom.Namespace03 && start.Name.Local == "feed":
+		v.add(Warning, path, "Atom 0.3 is obsolete; use Atom 1.0 (RFC 4287)")
+		return atomDialect{legacy: true}
+	}
+	v.add(Error, path, "unknown root element <%s>; the document is not RSS 1.0, RSS 2.0 nor Atom", start.Name.Local)
+	return nil
+}
+
+// scan reads all tokens from r, and records positions of elements.
+// It checks values of dates and numbers, and removes invalid ones or
+// rewrites tolerated ones so that decoders of dialects don't fail. It returns the root element if the
+// document is a well-formed feed of a known dialect.
+func (v *validator) scan(r io.Reader) (*xml.StartElement, error) {
+	d := xml.NewDecoder(r)
+	var (
+		stack []*element
+		root  *xml.StartElement
+		value *valueCheck // element of which value is being checked
+	)
+	for {
+		line, column := d.InputPos()
+		tok, err := d.Token()
+		if err == io.EOF {
+			switch {
+			case root == nil:
+				v.add(Error, "", "no root element")
+				return nil, nil
+			case len(stack) > 0:
+				v.add(Error, stack[len(stack)-1].path, "unexpected EOF")
+				return nil, nil
+			}
+			return root, nil
+		}
+		if err != nil {
+			var e *xml.SyntaxError
+			if !errors.As(err, &e) {
+				return nil, err
+			}
+			path := ""
+			if len(stack) > 0 {
+				path = stack[len(stack)-1].path
+			}
+			line, column = d.InputPos()
+			v.findings = append(v.findings, &Finding{
+				Severity: Error,
+				Path:     path,
+				Line:     line,
+				Column:   column,
+				Message:  e.Msg,
+			})
+			return nil, nil
+		}
+		tok = xml.CopyToken(tok)
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path := t.Name.Local
+			if n := len(stack); n > 0 {
+				parent := stack[n-1]
+				if parent.counts == nil {
+					parent.counts = make(map[string]int)
+				}
+				parent.counts[t.Name.Local]++
+				path = parent.path + "/" + elementName(t.Name.Local, parent.counts[t.Name.Local]-1)
+			} else if root == nil {
+				// tokens before the root element are not needed by decoders.
+				v.pos[path] = position{line, column}
+				if v.dialect = v.detect(t, path); v.dialect == nil {
+					return nil, nil
+				}
+				t.Attr = v.checkAttrs(t, path)
+				root = &t
+				stack = append(stack, &element{path: path})
+				v.tokens = []xml.Token{t}
+				continue
+			}
+			if _, ok := v.pos[path]; !ok {
+				v.pos[path] = position{line, column}
+			}
+			stack = append(stack, &element{path: path})
+			t.Attr = v.checkAttrs(t, path)
+			if value != nil {
+				value.nested = true
+			} else if kind := v.dialect.valueKind(t.Name, xml.Name{}); kind != noValue {
+				value = &valueCheck{kind: kind, path: path, depth: len(stack), start: len(v.tokens)}
+			}
+			tok = t
+		case xml.EndElement:
+			if n := len(stack); n > 0 {
+				stack = stack[:n-1]
+			}
+			if value != nil && len(stack) < value.depth {
+				// closed the element of the value.
+				v.tokens = append(v.tokens, tok)
+				if !value.nested {
+					text := value.text.String()
+					switch s, ok := v.checkValue(value.kind, value.path, text); {
+					case !ok:
+						v.tokens = v.tokens[:value.start]
+					case s != text:
+						v.tokens = append(v.tokens[:value.start+1], xml.CharData(s), tok)
+					}
+				}
+				value = nil
+				continue
+			}
+		case xml.CharData:
+			if value != nil {
+				value.text.Write(t)
+			}
+		}
+		v.tokens = append(v.tokens, tok)
+	}
+}
+
+// valueCheck is an element of which value is being checked while scanning.
+type valueCheck struct {
+	kind   valueKind
+	path   string
+	depth  int // depth of the element
+	start  int // index of the start element in validator.tokens
+	text   strings.Builder
+	nested bool // the element has child elements
+}
+
+// xmlNamespace is the namespace of xml:lang and xml:base.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// checkAttrs checks values of attributes of start.
+// It returns attributes without invalid ones.
+func (v *validator) checkAttrs(start xml.StartElement, path string) []xml.Attr {
+	attrs := make([]xml.Attr, 0, len(start.Attr))
+	for _, a := range start.Attr {
+		if a.Name.Space == xmlNamespace && a.Name.Local == "lang" && a.Value != "" && !isLanguageTag(a.Value) {
+			v.add(Warning, path, "xml:lang %q is not a language tag", a.Value)
+		}
+		if kind := v.dialect.valueKind(start.Name, a.Name); kind != noValue {
+			s, ok := v.checkValue(kind, path+"@"+a.Name.Local, a.Value)
+			if !ok {
+				continue
+			}
+			a.Value = s
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// validate decodes tokens with the decoder of the dialect, then validates the document.
+func (v *validator) validate(start *xml.StartElement) {
+	d := xml.NewTokenDecoder(&tokenReader{tokens: v.tokens})
+	if _, err := d.Token(); err != nil {
+		v.add(Error, start.Name.Local, "can't decode the document: %v", err)
+		return
+	}
+	if err := v.dialect.validate(v, d, start); err != nil {
+		v.add(Error, start.Name.Local, "can't decode the document: %v", err)
+	}
+}
+
+// tokenReader is a xml.TokenReader that reads tokens in order.
+type tokenReader struct {
+	tokens []xml.Token
+}
+
+func (r *tokenReader) Token() (xml.Token, error) {
+	if len(r.tokens) == 0 {
+		return nil, io.EOF
+	}
+	tok := r.tokens[0]
+	r.tokens = r.tokens[1:]
+	return tok, nil
+}
+
+// elementName returns the name of i-th child element of the name in a path.
+func elementName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s[%d]", name, i+1)
+}
+
+// checkURL checks that s is an absolute URL.
+func (v *validator) checkURL(sev Severity, path, s string) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	switch {
+	case err != nil:
+		v.add(sev, path, "%q is not a URL", s)
+	case !u.IsAbs():
+		v.add(sev, path, "%q is not an absolute URL", s)
+	}
+}
+
+// checkURLRef checks that s is a URL that might be relative.
+func (v *validator) checkURLRef(sev Severity, path, s string) {
+	if _, err := url.Parse(strings.TrimSpace(s)); err != nil {
+		v.add(sev, path, "%q is not a URL", s)
+	}
+}
+
+// checkEmail checks that s is an email address that might be followed by a name,
+// such as "joe@example.com (Joe Smith)".
+func (v *validator) checkEmail(sev Severity, path, s string) {
+	if _, err := mail.ParseAddress(s); err != nil {
+		v.add(sev, path, "%q is not an email address", s)
+	}
+}
+
+// checkMediaType checks that s is a MIME media type.
+func (v *validator) checkMediaType(sev Severity, path, s string) {
+	typ, _, err := mime.ParseMediaType(s)
+	if err != nil || !strings.Contains(typ, "/") {
+		v.add(sev, path, "%q is not a MIME media type", s)
+	}
+}
+
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// isLanguageTag reports whether s is a language tag of RFC 3066 or later.
+func isLanguageTag(s string) bool {
+	return languageTagPattern.MatchString(s)
+}
+
+// checkUnique reports duplicates of ids; paths are paths of elements of each id.
+func (v *validator) checkUnique(what string, ids, paths []string) {
+	seen := make(map[string]string)
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			v.add(Error, paths[i], "duplicate %s %q; it is also used at %s", what, id, first)
+			continue
+		}
+		seen[id] = paths[i]
+	}
+}
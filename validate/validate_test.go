@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// summarize returns findings in the form of "line:column: severity: path".
+func summarize(findings []*Finding) []string {
+	a := make([]string, 0, len(findings))
+	for _, f := range findings {
+		s := f.String()
+		if i := strings.LastIndex(s, ": "); i >= 0 {
+			s = s[:i]
+		}
+		a = append(a, s)
+	}
+	return a
+}
+
+type validateTest struct {
+	Name      string
+	XMLString string
+	Want      []string // summarized findings
+}
+
+func testValidate(t *testing.T, tab []validateTest) {
+	t.Helper()
+	for _, v := range tab {
+		findings, err := Validate(strings.NewReader(v.XMLString))
+		if err != nil {
+			t.Errorf("%s: Validate = %v", v.Name, err)
+			continue
+		}
+		if a := summarize(findings); !reflect.DeepEqual(a, v.Want) {
+			t.Errorf("%s: Validate = %q; want %q", v.Name, a, v.Want)
+			for _, f := range findings {
+				t.Logf("\t%v", f)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testValidate(t, []validateTest{
+		{
+			Name:      "empty",
+			XMLString: "",
+			Want:      []string{"0:0: error: "},
+		},
+		{
+			Name:      "not well-formed",
+			XMLString: "<rss version=\"2.0\">\n<channel><title>a</title></chanel></rss>",
+			Want:      []string{"2:35: error: rss/channel"},
+		},
+		{
+			Name:      "unknown root",
+			XMLString: "<?xml version=\"1.0\"?>\n<html></html>",
+			Want:      []string{"2:1: error: html"},
+		},
+		{
+			Name:      "xml:lang",
+			XMLString: "<feed xmlns=\"http://www.w3.org/2005/Atom\" xml:lang=\"en_US\">\n<id>urn:a</id><title>a</title><updated>2008-01-01T00:00:00Z</updated><link rel=\"self\" href=\"http://example.com/\"/></feed>",
+			Want:      []string{"1:1: warning: feed"},
+		},
+	})
+}
+
+func TestFindingString(t *testing.T) {
+	f := &Finding{
+		Severity: Warning,
+		Path:     "rss/channel/language",
+		Line:     3,
+		Column:   5,
+		Message:  `"en us" is not a language tag`,
+	}
+	want := `3:5: warning: rss/channel/language: "en us" is not a language tag`
+	if s := f.String(); s != want {
+		t.Errorf("String() = %q; want %q", s, want)
+	}
+}
+
+func TestIsLanguageTag(t *testing.T) {
+	tab := []struct {
+		S    string
+		Want bool
+	}{
+		{S: "en", Want: true},
+		{S: "en-US", Want: true},
+		{S: "zh-Hant-TW", Want: true},
+		{S: "en_US", Want: false},
+		{S: "en us", Want: false},
+		{S: "", Want: false},
+	}
+	for _, v := range tab {
+		if ok := isLanguageTag(v.S); ok != v.Want {
+			t.Errorf("isLanguageTag(%q) = %t; want %t", v.S, ok, v.Want)
+		}
+	}
+}
+
+func FuzzValidate(f *testing.F) {
+	f.Add(`<rss version="2.0"><channel><title>a</title><item><pubDate>x</pubDate></item></channel></rss>`)
+	f.Add(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><updated>x</updated></entry></feed>`)
+	f.Add(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel/></rdf:RDF>`)
+	f.Fuzz(func(t *testing.T, s string) {
+		Validate(strings.NewReader(s))
+	})
+}
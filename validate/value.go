@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lufia/news/rss2"
+)
+
+// valueKind is the kind of values of elements or attributes that decoders parse.
+type valueKind int
+
+const (
+	noValue     valueKind = iota
+	rfc822Date            // RSS 2.0
+	rfc3339Date           // Atom
+	w3cDate               // Dublin Core and Atom 0.3
+	integer
+)
+
+// rfc822Zones are time zones that RFC 822 defines, except military zones.
+var rfc822Zones = map[string]bool{
+	"UT":  true,
+	"GMT": true,
+	"EST": true,
+	"EDT": true,
+	"CST": true,
+	"CDT": true,
+	"MST": true,
+	"MDT": true,
+	"PST": true,
+	"PDT": true,
+	"Z":   true,
+}
+
+// rfc822Layouts are layouts of RFC 822 dates; the day of week and seconds are
+// optional. Zones of them are replaced with numeric offsets as well.
+var rfc822Layouts = []string{
+	rss2.RFC2822Z,
+	"Mon, _2 Jan 2006 15:04 MST",
+	"_2 Jan 2006 15:04:05 MST",
+	"_2 Jan 2006 15:04 MST",
+}
+
+// parseRFC822 parses s as a RFC 822 date. It returns the layout that matched s.
+// Years of 2 digits are accepted as RFC 822 defines, though RSS 2.0 recommends 4 digits.
+func parseRFC822(s string) (time.Time, string, error) {
+	var err error
+	for _, l := range rfc822Layouts {
+		for _, layout := range []string{
+			strings.Replace(l, "MST", "-0700", 1),
+			l,
+			strings.Replace(strings.Replace(l, "MST", "-0700", 1), "2006", "06", 1),
+			strings.Replace(l, "2006", "06", 1),
+		} {
+			var t time.Time
+			if t, err = time.Parse(layout, s); err == nil {
+				return t, layout, nil
+			}
+		}
+	}
+	return time.Time{}, "", err
+}
+
+// w3cDateLayouts are layouts of W3C Date and Time Formats.
+var w3cDateLayouts = []string{
+	"2006",
+	"2006-01",
+	"2006-01-02",
+	"2006-01-02T15:04Z07:00",
+	time.RFC3339Nano,
+}
+
+// checkValue checks s, the value of path, as kind. It returns the value
+// that the decoder can parse, and reports whether s is acceptable;
+// the element or the attribute of the value that isn't is removed from the document.
+func (v *validator) checkValue(kind valueKind, path, s string) (string, bool) {
+	t := strings.TrimSpace(s)
+	switch kind {
+	case rfc822Date:
+		d, layout, err := parseRFC822(t)
+		if err != nil {
+			v.add(Error, path, "%q is not a RFC 822 date", s)
+			return s, false
+		}
+		if !strings.Contains(layout, "2006") {
+			v.add(Warning, path, "year of %q has 2 digits; use 4 digits", s)
+		}
+		if strings.HasSuffix(layout, "MST") {
+			if zone, _ := d.Zone(); !rfc822Zones[zone] {
+				v.add(Warning, path, "time zone %q of %q is not defined in RFC 822; use a numeric offset", zone, s)
+			}
+		}
+		if s != t {
+			// decoders of dates don't trim spaces.
+			v.add(Error, path, "date %q has leading or trailing spaces", s)
+			return s, false
+		}
+		// the decoder accepts only the forms with the day of week and seconds.
+		switch {
+		case layout == rss2.RFC2822 || layout == rss2.RFC2822Z:
+		case strings.HasSuffix(layout, "MST"):
+			s = d.Format(rss2.RFC2822Z)
+		default:
+			s = d.Format(rss2.RFC2822)
+		}
+	case rfc3339Date:
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			v.add(Error, path, "%q is not a RFC 3339 date", s)
+			return s, false
+		}
+		if s != t {
+			v.add(Error, path, "date %q has leading or trailing spaces", s)
+			return s, false
+		}
+	case w3cDate:
+		for _, l := range w3cDateLayouts {
+			if _, err := time.Parse(l, t); err == nil {
+				return s, true
+			}
+		}
+		v.add(Error, path, "%q is not a W3C date", s)
+	case integer:
+		n, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			v.add(Error, path, "%q is not an integer", s)
+			return s, false
+		}
+		if n < 0 {
+			v.add(Error, path, "%d is negative", n)
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,556 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lufia/news"
+)
+
+// Lease is a subscription that a Hub stores.
+type Lease struct {
+	Topic    string
+	Callback string
+	Secret   string // empty if the subscriber did not request signatures
+	Expires  time.Time
+}
+
+// Store stores leases of subscriptions.
+type Store interface {
+	// Put adds l, or replaces the lease that has the same topic and callback.
+	Put(ctx context.Context, l *Lease) error
+
+	// Delete deletes the lease of the topic and the callback.
+	// It is not an error that the lease does not exist.
+	Delete(ctx context.Context, topic, callback string) error
+
+	// Leases returns leases of the topic, including expired ones.
+	Leases(ctx context.Context, topic string) ([]*Lease, error)
+}
+
+// MemoryStore is a Store that keeps leases in memory.
+// The zero value is an empty store ready to use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	leases map[string]map[string]*Lease // by topic, then by callback
+}
+
+func (m *MemoryStore) Put(ctx context.Context, l *Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases == nil {
+		m.leases = make(map[string]map[string]*Lease)
+	}
+	if m.leases[l.Topic] == nil {
+		m.leases[l.Topic] = make(map[string]*Lease)
+	}
+	v := *l
+	m.leases[l.Topic][l.Callback] = &v
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, topic, callback string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.leases[topic], callback)
+	if len(m.leases[topic]) == 0 {
+		delete(m.leases, topic)
+	}
+	return nil
+}
+
+func (m *MemoryStore) Leases(ctx context.Context, topic string) ([]*Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := make([]*Lease, 0, len(m.leases[topic]))
+	for _, l := range m.leases[topic] {
+		v := *l
+		a = append(a, &v)
+	}
+	return a, nil
+}
+
+const (
+	defaultLease          = 10 * 24 * time.Hour
+	defaultMaxLease       = 30 * 24 * time.Hour
+	defaultMaxRetries     = 5
+	defaultRetryInterval  = time.Minute
+	defaultTimeout        = 30 * time.Second
+	defaultPublishTimeout = 10 * time.Minute
+
+	// maxSecretLength is the limit of hub.secret in bytes.
+	maxSecretLength = 200
+)
+
+// Hub is a WebSub hub. It is a http.Handler that accepts
+// subscribe, unsubscribe and publish requests.
+//
+// When a publisher pings the Hub, it fetches the topic, and distributes
+// the content to subscribers if the content has new articles.
+//
+// The Hub sends requests to URLs that anyone who can reach it submits:
+// it fetches topics of publish pings, and it requests callbacks of
+// subscriptions. Unless AllowTopic is set, it fetches any topic, including
+// hosts in private networks. A Hub that is reachable from untrusted networks
+// should set AllowTopic, and a Client that refuses to dial private addresses.
+type Hub struct {
+	// Store stores leases; nil means a MemoryStore.
+	Store Store
+
+	// Client sends requests to subscribers and publishers;
+	// nil means http.DefaultClient.
+	Client *http.Client
+
+	// URL is the public URL of the Hub. If it is not empty, the Hub links it
+	// with rel="hub" in content distribution requests.
+	URL string
+
+	// AllowTopic reports whether the Hub accepts subscriptions to the topic
+	// and publish pings of it; nil means the Hub accepts all topics.
+	// See the security note of Hub.
+	AllowTopic func(topic string) bool
+
+	// DefaultLease is the lease of subscriptions that don't specify it,
+	// and MaxLease is the maximum of leases. Zero means 10 days and 30 days.
+	DefaultLease time.Duration
+	MaxLease     time.Duration
+
+	// MaxRetries is the number of retries of failed content distributions,
+	// and RetryInterval is the interval before the first retry; it doubles
+	// after each retry. Zero means 5 retries and a minute.
+	MaxRetries    int
+	RetryInterval time.Duration
+
+	// Timeout is the limit of each request to subscribers and publishers,
+	// including reading the response. Zero means 30 seconds.
+	Timeout time.Duration
+
+	// PublishTimeout is the limit of each Publish, including the fetch of
+	// the topic and retries of distributions; distributions that are not
+	// finished by then are given up. Zero means 10 minutes.
+	PublishTimeout time.Duration
+
+	// ErrorLog logs errors of verifications and distributions;
+	// nil means the standard logger of the log package.
+	ErrorLog *log.Logger
+
+	mu    sync.Mutex
+	store MemoryStore
+	seen  map[string]map[string]bool // IDs of articles of each topic at the last fetch
+}
+
+func (h *Hub) storage() Store {
+	if h.Store == nil {
+		return &h.store
+	}
+	return h.Store
+}
+
+func (h *Hub) client() *http.Client {
+	if h.Client == nil {
+		return http.DefaultClient
+	}
+	return h.Client
+}
+
+func (h *Hub) timeout() time.Duration {
+	if h.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return h.Timeout
+}
+
+func (h *Hub) publishTimeout() time.Duration {
+	if h.PublishTimeout <= 0 {
+		return defaultPublishTimeout
+	}
+	return h.PublishTimeout
+}
+
+func (h *Hub) allowed(topic string) bool {
+	return h.AllowTopic == nil || h.AllowTopic(topic)
+}
+
+func (h *Hub) logf(format string, args ...interface{}) {
+	if h.ErrorLog != nil {
+		h.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// lease returns the lease that is requested with s.
+func (h *Hub) lease(s string) time.Duration {
+	d, max := h.DefaultLease, h.MaxLease
+	if d <= 0 {
+		d = defaultLease
+	}
+	if max <= 0 {
+		max = defaultMaxLease
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+		// compare in seconds; a large n overflows time.Duration.
+		if n > int64(max/time.Second) {
+			d = max
+		} else {
+			d = time.Duration(n) * time.Second
+		}
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// ServeHTTP accepts requests from subscribers and publishers.
+// Verifications of intent and content distributions run in background.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form := r.PostForm
+	switch mode := form.Get("hub.mode"); mode {
+	case "subscribe", "unsubscribe":
+		l := &Lease{
+			Topic:    form.Get("hub.topic"),
+			Callback: form.Get("hub.callback"),
+			Secret:   form.Get("hub.secret"),
+		}
+		if err := validateLease(l); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lease := h.lease(form.Get("hub.lease_seconds"))
+		w.WriteHeader(http.StatusAccepted)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), h.timeout())
+			defer cancel()
+			if err := h.verify(ctx, mode, l, lease); err != nil {
+				h.logf("websub: %s %s: %v", mode, l.Callback, err)
+			}
+		}()
+	case "publish":
+		topic := form.Get("hub.url")
+		if topic == "" {
+			topic = form.Get("hub.topic")
+		}
+		if !isHTTPURL(topic) {
+			http.Error(w, "invalid hub.url", http.StatusBadRequest)
+			return
+		}
+		if !h.allowed(topic) {
+			http.Error(w, "topic is not allowed", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		go func() {
+			// Publish is limited by h.publishTimeout.
+			if err := h.Publish(context.Background(), topic); err != nil {
+				h.logf("websub: publish %s: %v", topic, err)
+			}
+		}()
+	default:
+		http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+	}
+}
+
+func validateLease(l *Lease) error {
+	switch {
+	case l.Topic == "":
+		return errors.New("hub.topic is required")
+	case !isHTTPURL(l.Callback):
+		return errors.New("hub.callback must be a HTTP URL")
+	case len(l.Secret) >= maxSecretLength:
+		return errors.New("hub.secret is too long")
+	}
+	return nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// verify verifies the intent of the subscriber of l, then stores or deletes l.
+// If the topic is not allowed, verify notifies the subscriber of denial instead.
+func (h *Hub) verify(ctx context.Context, mode string, l *Lease, lease time.Duration) error {
+	if mode == "subscribe" && !h.allowed(l.Topic) {
+		_, err := h.get(ctx, l.Callback, url.Values{
+			"hub.mode":   {"denied"},
+			"hub.topic":  {l.Topic},
+			"hub.reason": {"topic is not allowed"},
+		})
+		return err
+	}
+	challenge := randomString(16)
+	q := url.Values{
+		"hub.mode":      {mode},
+		"hub.topic":     {l.Topic},
+		"hub.challenge": {challenge},
+	}
+	if mode == "subscribe" {
+		q.Set("hub.lease_seconds", strconv.Itoa(int(lease/time.Second)))
+	}
+	body, err := h.get(ctx, l.Callback, q)
+	if err != nil {
+		return err
+	}
+	if string(body) != challenge {
+		return errors.New("challenge mismatch")
+	}
+	if mode == "unsubscribe" {
+		return h.deleteLease(ctx, l.Topic, l.Callback)
+	}
+	l.Expires = time.Now().Add(lease)
+	return h.storage().Put(ctx, l)
+}
+
+// get sends a GET request with query q to the callback, then returns the body of the response.
+func (h *Hub) get(ctx context.Context, callback string, q url.Values) ([]byte, error) {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return nil, err
+	}
+	v := u.Query()
+	for k, a := range q {
+		v[k] = a
+	}
+	u.RawQuery = v.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := h.do(ctx, req, 1024)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("callback responded %s", resp.Status)
+	}
+	return body, nil
+}
+
+// do sends req within h.timeout, then returns the response and
+// its body that is read up to n bytes.
+func (h *Hub) do(ctx context.Context, req *http.Request, n int64) (*http.Response, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout())
+	defer cancel()
+	resp, err := h.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, n))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// content is the content of a topic that is distributed to subscribers.
+type content struct {
+	topic       string
+	contentType string
+	body        []byte
+}
+
+// Publish fetches the topic, and distributes the content to subscribers of
+// the topic if it has articles that were not in the previous fetch.
+// Publish does not fetch the topic if it has no subscribers.
+// Publish returns after all distributions are finished or given up,
+// and it gives up at h.PublishTimeout at the latest.
+func (h *Hub) Publish(ctx context.Context, topic string) error {
+	ctx, cancel := context.WithTimeout(ctx, h.publishTimeout())
+	defer cancel()
+	leases, err := h.activeLeases(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if len(leases) == 0 {
+		return nil
+	}
+	c, err := h.fetch(ctx, topic)
+	if err != nil {
+		return err
+	}
+	feed, err := news.ParseWithOptions(bytes.NewReader(c.body), &news.ParseOptions{
+		Context: ctx,
+		BaseURL: topic,
+	})
+	if err != nil {
+		return err
+	}
+	if len(h.update(topic, feed)) == 0 {
+		return nil
+	}
+	var wg sync.WaitGroup
+	for _, l := range leases {
+		wg.Add(1)
+		go func(l *Lease) {
+			defer wg.Done()
+			if err := h.distribute(ctx, l, c); err != nil {
+				h.logf("websub: distribute %s to %s: %v", topic, l.Callback, err)
+			}
+		}(l)
+	}
+	wg.Wait()
+	return nil
+}
+
+// activeLeases returns leases of the topic that are not expired.
+// It deletes expired leases from the store, and forgets articles of
+// the topic if it has no leases.
+func (h *Hub) activeLeases(ctx context.Context, topic string) ([]*Lease, error) {
+	leases, err := h.storage().Leases(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	a := leases[:0]
+	for _, l := range leases {
+		if !l.Expires.IsZero() && l.Expires.Before(now) {
+			if err := h.storage().Delete(ctx, l.Topic, l.Callback); err != nil {
+				h.logf("websub: delete %s: %v", l.Callback, err)
+			}
+			continue
+		}
+		a = append(a, l)
+	}
+	if len(a) == 0 {
+		h.forget(topic)
+	}
+	return a, nil
+}
+
+// deleteLease deletes the lease of the topic and the callback,
+// and forgets articles of the topic if it was the last lease.
+func (h *Hub) deleteLease(ctx context.Context, topic, callback string) error {
+	if err := h.storage().Delete(ctx, topic, callback); err != nil {
+		return err
+	}
+	_, err := h.activeLeases(ctx, topic)
+	return err
+}
+
+func (h *Hub) fetch(ctx context.Context, topic string) (*content, error) {
+	req, err := http.NewRequest(http.MethodGet, topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := h.do(ctx, req, news.DefaultLimits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", topic, resp.Status)
+	}
+	return &content{
+		topic:       topic,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        body,
+	}, nil
+}
+
+// update records articles of feed as the latest state of the topic,
+// and returns articles that are new since the previous update.
+func (h *Hub) update(topic string, feed *news.Feed) []*news.Article {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seen == nil {
+		h.seen = make(map[string]map[string]bool)
+	}
+	prev := h.seen[topic]
+	ids := make(map[string]bool, len(feed.Articles))
+	var a []*news.Article
+	for _, p := range feed.Articles {
+		ids[p.ID] = true
+		if !prev[p.ID] {
+			a = append(a, p)
+		}
+	}
+	h.seen[topic] = ids
+	return a
+}
+
+// forget drops articles of the topic that update recorded.
+func (h *Hub) forget(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.seen, topic)
+}
+
+// distribute sends c to the subscriber of l. It retries failed requests
+// with exponential backoff. If the subscriber responds 410 Gone,
+// distribute deletes l.
+func (h *Hub) distribute(ctx context.Context, l *Lease, c *content) error {
+	retries, interval := h.MaxRetries, h.RetryInterval
+	if retries <= 0 {
+		retries = defaultMaxRetries
+	}
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	var err error
+	for i := 0; ; i++ {
+		var code int
+		code, err = h.post(ctx, l, c)
+		switch {
+		case err == nil && code/100 == 2:
+			return nil
+		case err == nil && code == http.StatusGone:
+			return h.deleteLease(ctx, l.Topic, l.Callback)
+		case err == nil:
+			err = fmt.Errorf("callback responded %d", code)
+		}
+		if i >= retries {
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		interval *= 2
+	}
+}
+
+// post sends a content distribution request of c to the subscriber of l.
+func (h *Hub) post(ctx context.Context, l *Lease, c *content) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, l.Callback, bytes.NewReader(c.body))
+	if err != nil {
+		return 0, err
+	}
+	if c.contentType != "" {
+		req.Header.Set("Content-Type", c.contentType)
+	}
+	if h.URL != "" {
+		req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, h.URL))
+	}
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, c.topic))
+	if l.Secret != "" {
+		req.Header.Set("X-Hub-Signature", sign("sha256", l.Secret, c.body))
+	}
+	resp, _, err := h.do(ctx, req, 1024)
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
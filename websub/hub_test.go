@@ -0,0 +1,425 @@
+package websub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lufia/news"
+)
+
+// testPublisher serves a feed that has entries of ids.
+type testPublisher struct {
+	hub string
+
+	mu  sync.Mutex
+	ids []string
+}
+
+func (p *testPublisher) add(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids = append(p.ids, id)
+}
+
+func (p *testPublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w.Header().Set("Content-Type", "application/atom+xml")
+	io.WriteString(w, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	io.WriteString(w, `<title>Example</title>`)
+	io.WriteString(w, `<link rel="self" href="http://`+r.Host+r.URL.Path+`"/>`)
+	io.WriteString(w, `<link rel="hub" href="`+p.hub+`"/>`)
+	for _, id := range p.ids {
+		io.WriteString(w, `<entry><id>`+id+`</id><title>`+id+`</title></entry>`)
+	}
+	io.WriteString(w, `</feed>`)
+}
+
+func waitLeases(t *testing.T, store Store, topic string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		leases, err := store.Leases(context.Background(), topic)
+		if err != nil {
+			t.Fatalf("Leases = %v", err)
+		}
+		if len(leases) == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Leases(%q) is not %d", topic, n)
+}
+
+func TestHub(t *testing.T) {
+	var store MemoryStore
+	hub := &Hub{
+		Store:    &store,
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+	hub.URL = hubServer.URL
+
+	pub := &testPublisher{hub: hubServer.URL}
+	pub.add("urn:example:1")
+	pubServer := httptest.NewServer(pub)
+	defer pubServer.Close()
+	topic := pubServer.URL + "/feed"
+
+	feeds := make(chan *news.Feed, 10)
+	sub := &Subscriber{
+		ErrorLog: log.New(io.Discard, "", 0),
+		Notify: func(s *Subscription, feed *news.Feed) {
+			feeds <- feed
+		},
+	}
+	subServer := httptest.NewServer(sub)
+	defer subServer.Close()
+	defer sub.Close()
+	sub.CallbackURL = subServer.URL + "/callback/"
+
+	resp, err := http.Get(topic)
+	if err != nil {
+		t.Fatalf("Get = %v", err)
+	}
+	feed, err := news.Parse(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	ctx := context.Background()
+	s, err := sub.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe = %v", err)
+	}
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if d := time.Until(s.Expires()); d < 9*24*time.Hour || d > 10*24*time.Hour {
+		t.Errorf("lease = %v; want 10 days", d)
+	}
+	waitLeases(t, &store, topic, 1)
+
+	if err := hub.Publish(ctx, topic); err != nil {
+		t.Fatalf("Publish = %v", err)
+	}
+	select {
+	case feed := <-feeds:
+		if len(feed.Articles) != 1 {
+			t.Errorf("len(Articles) = %d; want 1", len(feed.Articles))
+		}
+	default:
+		t.Fatalf("Notify is not called")
+	}
+
+	// no new articles.
+	if err := hub.Publish(ctx, topic); err != nil {
+		t.Fatalf("Publish = %v", err)
+	}
+	select {
+	case <-feeds:
+		t.Errorf("Notify is called without new articles")
+	default:
+	}
+
+	pub.add("urn:example:2")
+	resp, err = http.PostForm(hubServer.URL, url.Values{
+		"hub.mode": {"publish"},
+		"hub.url":  {topic},
+	})
+	if err != nil {
+		t.Fatalf("PostForm = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("publish: status = %d; want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	select {
+	case feed := <-feeds:
+		if len(feed.Articles) != 2 {
+			t.Errorf("len(Articles) = %d; want 2", len(feed.Articles))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Notify is not called")
+	}
+
+	if err := sub.Unsubscribe(ctx, s); err != nil {
+		t.Fatalf("Unsubscribe = %v", err)
+	}
+	waitLeases(t, &store, topic, 0)
+}
+
+func TestHubDenied(t *testing.T) {
+	hub := &Hub{
+		ErrorLog: log.New(io.Discard, "", 0),
+		AllowTopic: func(topic string) bool {
+			return strings.HasPrefix(topic, "http://example.com/")
+		},
+	}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+	sub := &Subscriber{ErrorLog: log.New(io.Discard, "", 0)}
+	subServer := httptest.NewServer(sub)
+	defer subServer.Close()
+	defer sub.Close()
+	sub.CallbackURL = subServer.URL
+
+	ctx := context.Background()
+	s, err := sub.SubscribeTopic(ctx, hubServer.URL, "http://example.org/feed")
+	if err != nil {
+		t.Fatalf("SubscribeTopic = %v", err)
+	}
+	if err := s.Wait(ctx); !errors.Is(err, ErrDenied) {
+		t.Errorf("Wait = %v; want %v", err, ErrDenied)
+	}
+
+	resp, err := http.PostForm(hubServer.URL, url.Values{
+		"hub.mode": {"publish"},
+		"hub.url":  {"http://example.org/feed"},
+	})
+	if err != nil {
+		t.Fatalf("PostForm = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("publish: status = %d; want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestHubPublishWithoutLeases(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetched int
+	)
+	pubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched++
+		mu.Unlock()
+	}))
+	defer pubServer.Close()
+
+	hub := &Hub{ErrorLog: log.New(io.Discard, "", 0)}
+	if err := hub.Publish(context.Background(), pubServer.URL+"/feed"); err != nil {
+		t.Fatalf("Publish = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetched != 0 {
+		t.Errorf("the topic is fetched %d times; want 0", fetched)
+	}
+}
+
+func TestHubTimeout(t *testing.T) {
+	done := make(chan struct{})
+	pubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer pubServer.Close()
+	defer close(done)
+	topic := pubServer.URL + "/feed"
+
+	var store MemoryStore
+	store.Put(context.Background(), &Lease{
+		Topic:    topic,
+		Callback: "http://example.com/callback",
+		Expires:  time.Now().Add(time.Hour),
+	})
+	hub := &Hub{
+		Store:    &store,
+		Timeout:  50 * time.Millisecond,
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	if err := hub.Publish(context.Background(), topic); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish = %v; want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHubBadRequest(t *testing.T) {
+	hub := &Hub{ErrorLog: log.New(io.Discard, "", 0)}
+	tab := []url.Values{
+		{"hub.mode": {"subscribe"}, "hub.callback": {"http://example.com/callback"}},
+		{"hub.mode": {"subscribe"}, "hub.topic": {"http://example.com/feed"}, "hub.callback": {"/callback"}},
+		{"hub.mode": {"subscribe"}, "hub.topic": {"http://example.com/feed"}, "hub.callback": {"http://example.com/callback"}, "hub.secret": {strings.Repeat("x", 200)}},
+		{"hub.mode": {"publish"}, "hub.url": {"example.com"}},
+		{"hub.mode": {"unknown"}},
+	}
+	for _, form := range tab {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		hub.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status = %d; want %d", form, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHubDistribute(t *testing.T) {
+	tab := []struct {
+		Name     string
+		Codes    []int // responses of the callback in order
+		Attempts int
+		Leases   int  // leases after the distribution
+		Seen     bool // whether the hub remembers articles of the topic
+	}{
+		{Name: "retry", Codes: []int{500, 503, 200}, Attempts: 3, Leases: 1, Seen: true},
+		{Name: "give up", Codes: []int{500, 500, 500, 500}, Attempts: 3, Leases: 1, Seen: true},
+		{Name: "gone", Codes: []int{410}, Attempts: 1, Leases: 0, Seen: false},
+	}
+	pub := &testPublisher{}
+	pub.add("urn:example:1")
+	pubServer := httptest.NewServer(pub)
+	defer pubServer.Close()
+	topic := pubServer.URL + "/feed"
+
+	for _, v := range tab {
+		var (
+			mu       sync.Mutex
+			attempts int
+		)
+		callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if !verifySignature(r.Header.Get("X-Hub-Signature"), "secret", body) {
+				t.Errorf("%s: invalid signature", v.Name)
+			}
+			mu.Lock()
+			code := v.Codes[attempts]
+			attempts++
+			mu.Unlock()
+			w.WriteHeader(code)
+		}))
+		var store MemoryStore
+		store.Put(context.Background(), &Lease{
+			Topic:    topic,
+			Callback: callback.URL,
+			Secret:   "secret",
+			Expires:  time.Now().Add(time.Hour),
+		})
+		hub := &Hub{
+			Store:         &store,
+			MaxRetries:    2,
+			RetryInterval: time.Millisecond,
+			ErrorLog:      log.New(io.Discard, "", 0),
+		}
+		if err := hub.Publish(context.Background(), topic); err != nil {
+			t.Errorf("%s: Publish = %v", v.Name, err)
+		}
+		callback.Close()
+		mu.Lock()
+		n := attempts
+		mu.Unlock()
+		if n != v.Attempts {
+			t.Errorf("%s: attempts = %d; want %d", v.Name, n, v.Attempts)
+		}
+		leases, _ := store.Leases(context.Background(), topic)
+		if len(leases) != v.Leases {
+			t.Errorf("%s: len(Leases) = %d; want %d", v.Name, len(leases), v.Leases)
+		}
+		if seen := hasSeen(hub, topic); seen != v.Seen {
+			t.Errorf("%s: seen = %t; want %t", v.Name, seen, v.Seen)
+		}
+	}
+}
+
+func TestHubPublishTimeout(t *testing.T) {
+	pub := &testPublisher{}
+	pub.add("urn:example:1")
+	pubServer := httptest.NewServer(pub)
+	defer pubServer.Close()
+	topic := pubServer.URL + "/feed"
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer callback.Close()
+
+	var store MemoryStore
+	store.Put(context.Background(), &Lease{
+		Topic:    topic,
+		Callback: callback.URL,
+		Expires:  time.Now().Add(time.Hour),
+	})
+	hub := &Hub{
+		Store:          &store,
+		RetryInterval:  time.Hour,
+		PublishTimeout: 100 * time.Millisecond,
+		ErrorLog:       log.New(io.Discard, "", 0),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- hub.Publish(context.Background(), topic)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Publish = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Publish does not give up retries at PublishTimeout")
+	}
+}
+
+func TestHubExpiredLease(t *testing.T) {
+	pub := &testPublisher{}
+	pub.add("urn:example:1")
+	pubServer := httptest.NewServer(pub)
+	defer pubServer.Close()
+	topic := pubServer.URL + "/feed"
+
+	var store MemoryStore
+	store.Put(context.Background(), &Lease{
+		Topic:    topic,
+		Callback: "http://example.com/callback",
+		Expires:  time.Now().Add(-time.Second),
+	})
+	hub := &Hub{Store: &store}
+	hub.update(topic, &news.Feed{Articles: []*news.Article{{ID: "urn:example:1"}}})
+	if err := hub.Publish(context.Background(), topic); err != nil {
+		t.Fatalf("Publish = %v", err)
+	}
+	leases, _ := store.Leases(context.Background(), topic)
+	if len(leases) != 0 {
+		t.Errorf("Leases = %v; want empty", leases)
+	}
+	if hasSeen(hub, topic) {
+		t.Errorf("articles of %s are remembered after the last lease expired", topic)
+	}
+}
+
+func hasSeen(hub *Hub, topic string) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	_, ok := hub.seen[topic]
+	return ok
+}
+
+func TestHubLease(t *testing.T) {
+	hub := &Hub{MaxLease: 24 * time.Hour}
+	tab := []struct {
+		S    string
+		Want time.Duration
+	}{
+		{S: "", Want: 24 * time.Hour},
+		{S: "3600", Want: time.Hour},
+		{S: "-1", Want: 24 * time.Hour},
+		{S: "172800", Want: 24 * time.Hour},
+		{S: "10000000000", Want: 24 * time.Hour},
+		{S: "99999999999999999999", Want: 24 * time.Hour},
+	}
+	for _, v := range tab {
+		if d := hub.lease(v.S); d != v.Want {
+			t.Errorf("lease(%q) = %v; want %v", v.S, d, v.Want)
+		}
+	}
+}
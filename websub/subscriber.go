@@ -0,0 +1,398 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lufia/news"
+)
+
+var (
+	// ErrDenied is the error that the hub denied the subscription.
+	ErrDenied = errors.New("websub: subscription denied")
+)
+
+// Subscriber subscribes to topics on hubs, then receives feeds that hubs push.
+// Subscriber is a http.Handler that must be served at CallbackURL;
+// it answers verification requests and receives contents of the topics.
+type Subscriber struct {
+	// CallbackURL is the public URL that the Subscriber is served at.
+	// Each subscription has its own callback URL under CallbackURL.
+	CallbackURL string
+
+	// Client sends requests to hubs; nil means http.DefaultClient.
+	Client *http.Client
+
+	// LeaseSeconds is the lease of subscriptions that the Subscriber requests;
+	// zero means the default of each hub.
+	LeaseSeconds int
+
+	// Notify is called with the feed that the hub of s has pushed.
+	Notify func(s *Subscription, feed *news.Feed)
+
+	// RenewError is called with the error if the Subscriber can't renew
+	// the lease of s before it expires. The Subscriber retries renewals
+	// with exponential backoff until then.
+	RenewError func(s *Subscription, err error)
+
+	// RetryInterval is the first interval between retries of renewals;
+	// zero means 1 second.
+	RetryInterval time.Duration
+
+	// ErrorLog logs errors of renewals and pushed contents;
+	// nil means the standard logger of the log package.
+	ErrorLog *log.Logger
+
+	mu   sync.Mutex
+	subs map[string]*Subscription // by the last element of the callback URL
+}
+
+// Subscription is a subscription to the topic on the hub.
+type Subscription struct {
+	Hub   string
+	Topic string
+
+	id       string
+	callback string
+	secret   string
+	mode     string // hub.mode that waits for verification
+	expires  time.Time
+	timer    *time.Timer   // renews the lease
+	retries  int           // number of failed renewals in a row
+	done     chan struct{} // closed when the subscription is verified or denied first
+	err      error
+	sub      *Subscriber
+}
+
+// Expires returns the time when the lease of s expires.
+// It returns zero time if s is not verified yet, or the lease is unlimited.
+func (s *Subscription) Expires() time.Time {
+	s.sub.mu.Lock()
+	defer s.sub.mu.Unlock()
+	return s.expires
+}
+
+// Wait waits for the hub to verify s. It returns an error that matches
+// ErrDenied if the hub denied s.
+func (s *Subscription) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finish records the result of the first verification of s.
+// The caller must hold s.sub.mu.
+func (s *Subscription) finish(err error) {
+	select {
+	case <-s.done:
+	default:
+		s.err = err
+		close(s.done)
+	}
+}
+
+// Subscribe subscribes to feed on the hub that feed advertises.
+// The topic is the self URL of feed. If feed has multiple hubs,
+// Subscribe tries them in order until one of them accepts the request.
+func (sub *Subscriber) Subscribe(ctx context.Context, feed *news.Feed) (*Subscription, error) {
+	topic := feed.SelfURL()
+	if topic == "" {
+		return nil, ErrNoTopic
+	}
+	hubs := feed.Hubs()
+	if len(hubs) == 0 {
+		return nil, ErrNoHub
+	}
+	var err error
+	for _, hub := range hubs {
+		var s *Subscription
+		if s, err = sub.SubscribeTopic(ctx, hub, topic); err == nil {
+			return s, nil
+		}
+	}
+	return nil, err
+}
+
+// SubscribeTopic subscribes to the topic on the hub.
+// The hub verifies the subscription asynchronously; use Wait to know the result.
+func (sub *Subscriber) SubscribeTopic(ctx context.Context, hub, topic string) (*Subscription, error) {
+	id := randomString(16)
+	callback, err := url.JoinPath(sub.CallbackURL, id)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscription{
+		Hub:      hub,
+		Topic:    topic,
+		id:       id,
+		callback: callback,
+		secret:   randomString(32),
+		mode:     "subscribe",
+		done:     make(chan struct{}),
+		sub:      sub,
+	}
+
+	// The hub might verify s before it responds.
+	sub.mu.Lock()
+	if sub.subs == nil {
+		sub.subs = make(map[string]*Subscription)
+	}
+	sub.subs[id] = s
+	sub.mu.Unlock()
+	if err := sub.request(ctx, s, "subscribe"); err != nil {
+		sub.remove(s)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Unsubscribe unsubscribes s. The Subscriber forgets s
+// when the hub verifies the request.
+func (sub *Subscriber) Unsubscribe(ctx context.Context, s *Subscription) error {
+	sub.mu.Lock()
+	s.mode = "unsubscribe"
+	sub.mu.Unlock()
+	return sub.request(ctx, s, "unsubscribe")
+}
+
+// Close stops renewals of all subscriptions, and forgets them.
+// It does not unsubscribe them from hubs.
+func (sub *Subscriber) Close() error {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	for id, s := range sub.subs {
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		delete(sub.subs, id)
+	}
+	return nil
+}
+
+func (sub *Subscriber) client() *http.Client {
+	if sub.Client == nil {
+		return http.DefaultClient
+	}
+	return sub.Client
+}
+
+func (sub *Subscriber) retryInterval() time.Duration {
+	if sub.RetryInterval <= 0 {
+		return time.Second
+	}
+	return sub.RetryInterval
+}
+
+func (sub *Subscriber) logf(format string, args ...interface{}) {
+	if sub.ErrorLog != nil {
+		sub.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// request sends the request of mode for s to the hub.
+func (sub *Subscriber) request(ctx context.Context, s *Subscription, mode string) error {
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {s.Topic},
+		"hub.callback": {s.callback},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.secret", s.secret)
+		if sub.LeaseSeconds > 0 {
+			form.Set("hub.lease_seconds", strconv.Itoa(sub.LeaseSeconds))
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := sub.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("websub: %s %s: %s: %s", mode, s.Topic, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// renew renews the lease of s before it expires. If the hub doesn't accept
+// the request, renew retries it later unless the lease will expire by then.
+func (sub *Subscriber) renew(s *Subscription) {
+	sub.mu.Lock()
+	if sub.subs[s.id] != s || s.mode == "unsubscribe" {
+		sub.mu.Unlock()
+		return
+	}
+	s.mode = "subscribe"
+	sub.mu.Unlock()
+	err := sub.request(context.Background(), s, "subscribe")
+	if err == nil {
+		return
+	}
+
+	sub.mu.Lock()
+	if sub.subs[s.id] != s || s.mode == "unsubscribe" {
+		sub.mu.Unlock()
+		return
+	}
+	delay := sub.retryInterval() << s.retries
+	if time.Now().Add(delay).Before(s.expires) {
+		s.retries++
+		s.timer = time.AfterFunc(delay, func() {
+			sub.renew(s)
+		})
+		sub.mu.Unlock()
+		sub.logf("websub: failed to renew the subscription; retry in %v: %v", delay, err)
+		return
+	}
+	sub.mu.Unlock()
+	sub.logf("websub: failed to renew the subscription: %v", err)
+	if sub.RenewError != nil {
+		sub.RenewError(s, err)
+	}
+}
+
+func (sub *Subscriber) lookup(id string) *Subscription {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.subs[id]
+}
+
+func (sub *Subscriber) remove(s *Subscription) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if sub.subs[s.id] == s {
+		delete(sub.subs, s.id)
+	}
+}
+
+// ServeHTTP answers verification requests with GET,
+// and receives contents of topics with POST.
+func (sub *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := sub.lookup(path.Base(r.URL.Path))
+	if s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		sub.verify(w, r, s)
+	case http.MethodPost:
+		sub.receive(w, r, s)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the verification request of intent, or the notice of denial.
+func (sub *Subscriber) verify(w http.ResponseWriter, r *http.Request, s *Subscription) {
+	q := r.URL.Query()
+	if q.Get("hub.topic") != s.Topic {
+		http.NotFound(w, r)
+		return
+	}
+	mode := q.Get("hub.mode")
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	switch mode {
+	case "denied":
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		delete(sub.subs, s.id)
+		err := ErrDenied
+		if reason := q.Get("hub.reason"); reason != "" {
+			err = fmt.Errorf("%w: %s", ErrDenied, reason)
+		}
+		s.finish(err)
+		w.WriteHeader(http.StatusOK)
+		return
+	case "subscribe", "unsubscribe":
+		if s.mode != mode {
+			http.NotFound(w, r)
+			return
+		}
+	default:
+		http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+		return
+	}
+	s.mode = ""
+	s.retries = 0
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if mode == "unsubscribe" {
+		delete(sub.subs, s.id)
+	} else {
+		s.expires = time.Time{}
+		if n, err := strconv.Atoi(q.Get("hub.lease_seconds")); err == nil && n > 0 {
+			lease := time.Duration(n) * time.Second
+			s.expires = time.Now().Add(lease)
+			s.timer = time.AfterFunc(lease-lease/10, func() {
+				sub.renew(s)
+			})
+		}
+		s.finish(nil)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, q.Get("hub.challenge"))
+}
+
+// receive receives the content of the topic, then notifies it.
+// Contents that are not signed with the secret of s are ignored.
+func (sub *Subscriber) receive(w http.ResponseWriter, r *http.Request, s *Subscription) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, news.DefaultLimits.MaxBytes))
+	if err != nil {
+		var e *http.MaxBytesError
+		if errors.As(err, &e) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	// The hub will retry delivery if the subscriber responds other than 2xx,
+	// but retries don't fix invalid contents.
+	w.WriteHeader(http.StatusOK)
+	if !verifySignature(r.Header.Get("X-Hub-Signature"), s.secret, body) {
+		sub.logf("websub: %s: ignored the content with invalid signature", s.Topic)
+		return
+	}
+	feed, err := news.ParseWithOptions(bytes.NewReader(body), &news.ParseOptions{
+		Context: r.Context(),
+		BaseURL: s.Topic,
+	})
+	if err != nil {
+		sub.logf("websub: %s: %v", s.Topic, err)
+		return
+	}
+	if sub.Notify != nil {
+		sub.Notify(s, feed)
+	}
+}
@@ -0,0 +1,324 @@
+package websub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lufia/news"
+)
+
+// testHub is a stand-in hub that verifies every request.
+type testHub struct {
+	t      *testing.T
+	lease  int
+	reason string // if not empty, testHub denies subscriptions
+	fail   bool   // if true, testHub fails requests; it is protected by mu
+
+	requests chan url.Values // requests from subscribers
+	verified chan url.Values // requests that subscribers verified
+
+	mu      sync.Mutex
+	secrets map[string]string // by callback URL
+}
+
+func newTestHub(t *testing.T) *testHub {
+	return &testHub{
+		t:        t,
+		requests: make(chan url.Values, 10),
+		verified: make(chan url.Values, 10),
+		secrets:  make(map[string]string),
+	}
+}
+
+func (h *testHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form := r.PostForm
+	h.requests <- form
+	h.mu.Lock()
+	fail := h.fail
+	h.mu.Unlock()
+	if fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	go h.verify(form)
+}
+
+func (h *testHub) verify(form url.Values) {
+	q := url.Values{
+		"hub.mode":      {form.Get("hub.mode")},
+		"hub.topic":     {form.Get("hub.topic")},
+		"hub.challenge": {"challenge"},
+	}
+	switch {
+	case h.reason != "":
+		q = url.Values{
+			"hub.mode":   {"denied"},
+			"hub.topic":  {form.Get("hub.topic")},
+			"hub.reason": {h.reason},
+		}
+	case form.Get("hub.mode") == "subscribe":
+		q.Set("hub.lease_seconds", fmt.Sprint(h.lease))
+	}
+	callback := form.Get("hub.callback")
+	resp, err := http.Get(callback + "?" + q.Encode())
+	if err != nil {
+		h.t.Errorf("verify: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if q.Get("hub.mode") == "denied" || string(body) != "challenge" {
+		return
+	}
+	h.mu.Lock()
+	if form.Get("hub.mode") == "subscribe" {
+		h.secrets[callback] = form.Get("hub.secret")
+	} else {
+		delete(h.secrets, callback)
+	}
+	h.mu.Unlock()
+	h.verified <- form
+}
+
+// publish pushes body to the callback; it signs body with secret if it is not empty.
+func (h *testHub) publish(callback, secret, body string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, callback, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/atom+xml")
+	if secret != "" {
+		req.Header.Set("X-Hub-Signature", sign("sha256", secret, []byte(body)))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (h *testHub) secret(callback string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.secrets[callback]
+}
+
+func waitForm(t *testing.T, c <-chan url.Values) url.Values {
+	t.Helper()
+	select {
+	case form := <-c:
+		return form
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+		return nil
+	}
+}
+
+func newTestSubscriber(t *testing.T, hub *testHub) (*Subscriber, *news.Feed) {
+	t.Helper()
+	hubServer := httptest.NewServer(hub)
+	t.Cleanup(hubServer.Close)
+	sub := &Subscriber{
+		LeaseSeconds: 60,
+		ErrorLog:     log.New(io.Discard, "", 0),
+	}
+	subServer := httptest.NewServer(sub)
+	t.Cleanup(subServer.Close)
+	t.Cleanup(func() { sub.Close() })
+	sub.CallbackURL = subServer.URL + "/callback/"
+
+	feed, err := news.Parse(strings.NewReader(`<feed xmlns="http://www.w3.org/2005/Atom">
+		<title>Example</title>
+		<link rel="self" href="http://example.com/feed"/>
+		<link rel="hub" href="` + hubServer.URL + `"/>
+	</feed>`))
+	if err != nil {
+		t.Fatalf("Parse = %v", err)
+	}
+	return sub, feed
+}
+
+const testContent = `<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Example</title>
+	<entry><id>urn:example:1</id><title>Hello</title></entry>
+</feed>`
+
+func TestSubscriber(t *testing.T) {
+	hub := newTestHub(t)
+	hub.lease = 60
+	sub, feed := newTestSubscriber(t, hub)
+	feeds := make(chan *news.Feed, 1)
+	sub.Notify = func(s *Subscription, feed *news.Feed) {
+		feeds <- feed
+	}
+
+	ctx := context.Background()
+	s, err := sub.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe = %v", err)
+	}
+	form := waitForm(t, hub.requests)
+	if mode := form.Get("hub.mode"); mode != "subscribe" {
+		t.Errorf("hub.mode = %q; want subscribe", mode)
+	}
+	if topic := form.Get("hub.topic"); topic != "http://example.com/feed" {
+		t.Errorf("hub.topic = %q; want %q", topic, "http://example.com/feed")
+	}
+	if lease := form.Get("hub.lease_seconds"); lease != "60" {
+		t.Errorf("hub.lease_seconds = %q; want 60", lease)
+	}
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if s.Expires().IsZero() {
+		t.Errorf("Expires() = zero")
+	}
+	callback := waitForm(t, hub.verified).Get("hub.callback")
+
+	if _, err := hub.publish(callback, hub.secret(callback), testContent); err != nil {
+		t.Fatalf("publish = %v", err)
+	}
+	select {
+	case feed := <-feeds:
+		if n := len(feed.Articles); n != 1 || feed.Articles[0].Title != "Hello" {
+			t.Errorf("Articles = %v", feed.Articles)
+		}
+	default:
+		t.Errorf("Notify is not called")
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		code, err := hub.publish(callback, secret, testContent)
+		if err != nil {
+			t.Fatalf("publish = %v", err)
+		}
+		if code != http.StatusOK {
+			t.Errorf("publish with secret %q: status = %d; want %d", secret, code, http.StatusOK)
+		}
+		select {
+		case <-feeds:
+			t.Errorf("Notify is called with secret %q", secret)
+		default:
+		}
+	}
+
+	if err := sub.Unsubscribe(ctx, s); err != nil {
+		t.Fatalf("Unsubscribe = %v", err)
+	}
+	waitForm(t, hub.requests)
+	waitForm(t, hub.verified)
+	code, err := hub.publish(callback, s.secret, testContent)
+	if err != nil {
+		t.Fatalf("publish = %v", err)
+	}
+	if code != http.StatusNotFound {
+		t.Errorf("publish after Unsubscribe: status = %d; want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestSubscriberDenied(t *testing.T) {
+	hub := newTestHub(t)
+	hub.reason = "not allowed"
+	sub, feed := newTestSubscriber(t, hub)
+	ctx := context.Background()
+	s, err := sub.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe = %v", err)
+	}
+	if err := s.Wait(ctx); !errors.Is(err, ErrDenied) {
+		t.Errorf("Wait = %v; want %v", err, ErrDenied)
+	}
+}
+
+func TestSubscriberRenew(t *testing.T) {
+	hub := newTestHub(t)
+	hub.lease = 1
+	sub, feed := newTestSubscriber(t, hub)
+	ctx := context.Background()
+	s, err := sub.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe = %v", err)
+	}
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	first := waitForm(t, hub.verified)
+	renewed := waitForm(t, hub.verified)
+	if renewed.Get("hub.callback") != first.Get("hub.callback") {
+		t.Errorf("renewed callback = %q; want %q", renewed.Get("hub.callback"), first.Get("hub.callback"))
+	}
+}
+
+func TestSubscriberRenewError(t *testing.T) {
+	hub := newTestHub(t)
+	hub.lease = 1
+	sub, feed := newTestSubscriber(t, hub)
+	sub.RetryInterval = 10 * time.Millisecond
+	errc := make(chan error, 1)
+	sub.RenewError = func(s *Subscription, err error) {
+		errc <- err
+	}
+	ctx := context.Background()
+	s, err := sub.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe = %v", err)
+	}
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	waitForm(t, hub.requests)
+	hub.mu.Lock()
+	hub.fail = true
+	hub.mu.Unlock()
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("RenewError is called with nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RenewError is not called")
+	}
+	if n := len(hub.requests); n < 2 {
+		t.Errorf("renewals = %d; want retries", n)
+	}
+}
+
+func TestSubscribeNoHub(t *testing.T) {
+	tab := []struct {
+		Links []*news.Link
+		Err   error
+	}{
+		{
+			Links: []*news.Link{{URL: "http://hub.example.com/", Rel: "hub"}},
+			Err:   ErrNoTopic,
+		},
+		{
+			Links: []*news.Link{{URL: "http://example.com/feed", Rel: "self"}},
+			Err:   ErrNoHub,
+		},
+	}
+	var sub Subscriber
+	for _, v := range tab {
+		feed := &news.Feed{Links: v.Links}
+		if _, err := sub.Subscribe(context.Background(), feed); err != v.Err {
+			t.Errorf("Subscribe(%v) = %v; want %v", v.Links, err, v.Err)
+		}
+	}
+}
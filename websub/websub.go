@@ -0,0 +1,70 @@
+// Package websub implements WebSub, the protocol that pushes updates of feeds
+// from publishers to subscribers through hubs.
+//
+// See https://www.w3.org/TR/websub/ for details.
+package websub
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+)
+
+var (
+	// ErrNoHub is the error that the feed advertises no hubs.
+	ErrNoHub = errors.New("websub: feed has no hub")
+
+	// ErrNoTopic is the error that the feed has no self link.
+	ErrNoTopic = errors.New("websub: feed has no self link")
+)
+
+// signatureHashes are hash functions that X-Hub-Signature header can use.
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// sign returns the value of X-Hub-Signature header of body
+// that is signed with secret by method.
+func sign(method, secret string, body []byte) string {
+	mac := hmac.New(signatureHashes[method], []byte(secret))
+	mac.Write(body)
+	return method + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature reports whether sig, the value of X-Hub-Signature header,
+// is a valid signature of body with secret.
+func verifySignature(sig, secret string, body []byte) bool {
+	method, s, ok := strings.Cut(sig, "=")
+	if !ok {
+		return false
+	}
+	h, ok := signatureHashes[method]
+	if !ok {
+		return false
+	}
+	want, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// randomString returns a random string that has n bytes of entropy.
+func randomString(n int) string {
+	p := make([]byte, n)
+	if _, err := rand.Read(p); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(p)
+}
@@ -0,0 +1,28 @@
+package websub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte("hello")
+	tab := []struct {
+		Sig  string
+		Want bool
+	}{
+		{Sig: sign("sha1", "secret", body), Want: true},
+		{Sig: sign("sha256", "secret", body), Want: true},
+		{Sig: sign("sha384", "secret", body), Want: true},
+		{Sig: sign("sha512", "secret", body), Want: true},
+		{Sig: sign("sha256", "other", body), Want: false},
+		{Sig: "md5=" + strings.TrimPrefix(sign("sha1", "secret", body), "sha1="), Want: false},
+		{Sig: "sha256=zz", Want: false},
+		{Sig: "", Want: false},
+	}
+	for _, v := range tab {
+		if ok := verifySignature(v.Sig, "secret", body); ok != v.Want {
+			t.Errorf("verifySignature(%q) = %t; want %t", v.Sig, ok, v.Want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package wfw implements the Well-Formed Web Comment API elements.
+package wfw
+
+import (
+	"encoding/xml"
+
+	"github.com/lufia/news/extension"
+)
+
+// Namespace is the namespace of the Comment API.
+const Namespace = "http://wellformedweb.org/CommentAPI/"
+
+// CommentAPI is the set of wfw:comment and wfw:commentRss.
+type CommentAPI struct {
+	Comment    string `xml:"http://wellformedweb.org/CommentAPI/ comment,omitempty"`    // URL to post comments
+	CommentRSS string `xml:"http://wellformedweb.org/CommentAPI/ commentRss,omitempty"` // URL of the comment feed
+}
+
+func init() {
+	extension.Register(Namespace, decode)
+}
+
+// decode decodes the Comment API elements to *CommentAPI.
+func decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var c CommentAPI
+	if err := d.DecodeElement(&c, &start); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
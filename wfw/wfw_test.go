@@ -0,0 +1,33 @@
+package wfw
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/lufia/news/extension"
+)
+
+func TestDecode(t *testing.T) {
+	s := `<item xmlns:wfw="http://wellformedweb.org/CommentAPI/">
+		<wfw:comment>http://example.com/comment/1</wfw:comment>
+		<wfw:commentRss>http://example.com/comments/1/feed</wfw:commentRss>
+	</item>`
+	var v struct {
+		Extensions []extension.Element `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", s, err)
+	}
+	p, err := extension.New(v.Extensions).Decode(Namespace)
+	if err != nil {
+		t.Fatalf("Decode(%q) = %v", Namespace, err)
+	}
+	want := &CommentAPI{
+		Comment:    "http://example.com/comment/1",
+		CommentRSS: "http://example.com/comments/1/feed",
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Decode(%q) = %#v; want %#v", Namespace, p, want)
+	}
+}